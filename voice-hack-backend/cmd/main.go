@@ -1,9 +1,24 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"net/http"
+	"os"
+	"voice-hack-backend/config"
 	handler "voice-hack-backend/handler"
+	insightsGenerateModel "voice-hack-backend/modules/tripPlanner/model/insightsGenerateModel"
+	insightsJobModel "voice-hack-backend/modules/tripPlanner/model/insightsJobModel"
+	notifier "voice-hack-backend/utilities/alertNotifier"
+	"voice-hack-backend/utilities/genaiService"
+	getdatafromvectordb "voice-hack-backend/utilities/getDataFromVectorDB"
+	"voice-hack-backend/utilities/insightStore"
+	llm "voice-hack-backend/utilities/llmService"
+	prompts "voice-hack-backend/utilities/promptTemplates"
+	"voice-hack-backend/utilities/redaction"
+	transcription "voice-hack-backend/utilities/transcriptionService"
+	urlMedia "voice-hack-backend/utilities/urlMedia"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -12,14 +27,57 @@ import (
 func main() {
 	fmt.Println("Starting the server...")
 
-	port := "8080"
+	// Load config from -config, CONFIG_PATH or ./config.yaml, with environment overrides
+	configPath := flag.String("config", os.Getenv("CONFIG_PATH"), "path to the YAML config file")
+	flag.Parse()
+	configRequired := *configPath != ""
+	if !configRequired {
+		*configPath = config.DefaultPath
+	}
+	cfg, cfgErr := config.Load(*configPath, configRequired)
+	if cfgErr != nil {
+		fmt.Println("Failed to load configuration: " + cfgErr.Error())
+		os.Exit(1)
+	}
+	config.Set(cfg)
+	llm.Configure(cfg)
+	genaiService.Configure(cfg.Gemini)
+	getdatafromvectordb.Configure(cfg.VectorDB)
+	urlMedia.Configure(cfg.Transcription)
+	transcription.Configure(cfg.Transcription)
+	redaction.Configure(cfg.Redaction)
+	notifier.Configure(cfg.Alerts)
+	if promptsErr := prompts.Configure(cfg.Prompts); promptsErr != nil {
+		fmt.Println("Failed to load prompt templates: " + promptsErr.Error())
+		os.Exit(1)
+	}
+	store, storeErr := insightStore.OpenSQLite(context.Background(), cfg.Store.Path)
+	if storeErr != nil {
+		fmt.Println("Failed to open insight store: " + storeErr.Error())
+		os.Exit(1)
+	}
+	defer store.Close()
+	insightStore.SetDefault(store)
+	if kbErr := insightsGenerateModel.LoadConcernCategories(context.Background()); kbErr != nil {
+		fmt.Println("Failed to load the concern knowledge base: " + kbErr.Error())
+		os.Exit(1)
+	}
+	if jobsErr := insightsJobModel.Start(cfg.Jobs); jobsErr != nil {
+		fmt.Println("Failed to start job workers: " + jobsErr.Error())
+		os.Exit(1)
+	}
+	if cfg.Alerts.Enabled {
+		insightsGenerateModel.StartAlertDispatcher(cfg.Alerts)
+	}
+
+	port := cfg.Server.Port
 	gin.SetMode(gin.ReleaseMode)
 	ginEngine := gin.New()
 
 	// Add CORS middleware with more permissive settings for development
 	ginEngine.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Allow all origins for development
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"*"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: false, // Set to false when using wildcard origins
@@ -0,0 +1,715 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is used when neither -config nor CONFIG_PATH is given
+const DefaultPath = "config.yaml"
+
+type Config struct {
+	Server        ServerConfig        `yaml:"server"`
+	LLM           LLMConfig           `yaml:"llm"`
+	Gemini        GeminiConfig        `yaml:"gemini"`
+	VectorDB      VectorDBConfig      `yaml:"vector_db"`
+	Transcription TranscriptionConfig `yaml:"transcription"`
+	Jobs          JobsConfig          `yaml:"jobs"`
+	Store         StoreConfig         `yaml:"store"`
+	Metrics       MetricsConfig       `yaml:"metrics"`
+	Redaction     RedactionConfig     `yaml:"redaction"`
+	Language      LanguageConfig      `yaml:"language"`
+	Prompts       PromptsConfig       `yaml:"prompts"`
+	Compliance    ComplianceConfig    `yaml:"compliance"`
+	History       HistoryConfig       `yaml:"history"`
+	ActionItems   ActionItemsConfig   `yaml:"action_items"`
+	Alerts        AlertsConfig        `yaml:"alerts"`
+}
+
+type ServerConfig struct {
+	Port               string `yaml:"port"`
+	MaxCallsPerRequest int    `yaml:"max_calls_per_request"` // call_data entries accepted by one generate request
+	MaxUploadMB        int    `yaml:"max_upload_mb"`         // total size of the audio files uploaded with one request
+}
+
+type LLMConfig struct {
+	Provider    string        `yaml:"provider"` // gateway or gemini
+	APIURL      string        `yaml:"api_url"`  // OpenAI-compatible chat completions URL
+	APIKey      string        `yaml:"api_key"`
+	Model       string        `yaml:"model"`
+	Temperature float64       `yaml:"temperature"`
+	Timeout     time.Duration `yaml:"timeout"`
+
+	RepairAttempts int `yaml:"repair_attempts"` // Re-prompts after an invalid JSON reply before giving up
+}
+
+type GeminiConfig struct {
+	APIKey string `yaml:"api_key"`
+	Model  string `yaml:"model"`
+}
+
+type VectorDBConfig struct {
+	PineconeAPIKey  string `yaml:"pinecone_api_key"`
+	PineconeHost    string `yaml:"pinecone_host"`
+	Namespace       string `yaml:"namespace"`
+	EmbeddingURL    string `yaml:"embedding_url"`
+	EmbeddingModel  string `yaml:"embedding_model"`
+	EmbeddingAPIKey string `yaml:"embedding_api_key"` // falls back to llm.api_key
+}
+
+type TranscriptionConfig struct {
+	Provider        string        `yaml:"provider"`   // media_service, whisper or fake
+	URL             string        `yaml:"url"`        // media service transcribe endpoint
+	StatusURL       string        `yaml:"status_url"` // media service status endpoint, {media_id} is replaced
+	ModID           string        `yaml:"mod_id"`     // module ID sent to the media service
+	Timeout         time.Duration `yaml:"timeout"`
+	DownloadTimeout time.Duration `yaml:"download_timeout"`
+	Workers         int           `yaml:"workers"` // calls transcribed, and checked for compliance, concurrently per request
+
+	PollInterval    time.Duration `yaml:"poll_interval"`     // first wait before polling a pending transcription
+	PollMaxInterval time.Duration `yaml:"poll_max_interval"` // the wait doubles up to this value
+	PollTimeout     time.Duration `yaml:"poll_timeout"`      // give up with ErrTranscriptionProcessing after this
+
+	Whisper WhisperConfig `yaml:"whisper"`
+	FakeDir string        `yaml:"fake_dir"` // transcripts served by the fake provider
+}
+
+// WhisperConfig points at an OpenAI-compatible /v1/audio/transcriptions server, e.g. a local whisper.cpp server
+type WhisperConfig struct {
+	URL      string `yaml:"url"` // base URL, /v1/audio/transcriptions is appended
+	APIKey   string `yaml:"api_key"`
+	Model    string `yaml:"model"`
+	Language string `yaml:"language"` // optional ISO-639-1 hint
+}
+
+type JobsConfig struct {
+	Dir       string `yaml:"dir"`        // one JSON file per job, survives restarts
+	Workers   int    `yaml:"workers"`    // background pipelines running at once
+	QueueSize int    `yaml:"queue_size"` // pending jobs before submissions are rejected
+
+	MaxRetries int           `yaml:"max_retries"` // re-runs of a job whose transcription is still processing
+	RetryDelay time.Duration `yaml:"retry_delay"` // wait before such a re-run
+	TTL        time.Duration `yaml:"ttl"`         // completed and failed jobs are deleted this long after they finish, 0 keeps them
+}
+
+type StoreConfig struct {
+	Path string `yaml:"path"` // SQLite database file
+}
+
+// MetricsConfig sets the thresholds of the call-quality alerts raised from timestamped transcripts
+type MetricsConfig struct {
+	HoldGap               time.Duration `yaml:"hold_gap"`                 // a silence at least this long counts as hold time
+	HoldAlert             time.Duration `yaml:"hold_alert"`               // total hold time above this raises an alert
+	SilenceAlert          time.Duration `yaml:"silence_alert"`            // a single silence above this raises an alert
+	MaxExecutiveTalkShare float64       `yaml:"max_executive_talk_share"` // executive share of talk time above this raises an alert
+	MaxInterruptions      int           `yaml:"max_interruptions"`        // executive interruptions above this raise an alert
+}
+
+// LanguageConfig sets the language of the insights and the optional translation of transcripts
+type LanguageConfig struct {
+	OutputLanguage      string `yaml:"output_language"`      // language of the insight text when the request sets none, e.g. en or hi
+	Translate           bool   `yaml:"translate"`            // translate transcripts into translate_to before generating insights
+	TranslateTo         string `yaml:"translate_to"`         // language the transcripts are normalized to
+	TranslationProvider string `yaml:"translation_provider"` // LLM provider used as translator, the request's provider when empty
+}
+
+// PromptsConfig chooses where the prompt templates are loaded from and the version used by default
+type PromptsConfig struct {
+	Dir     string `yaml:"dir"`     // <version>/<name>.tmpl files overriding or adding to the embedded templates
+	Version string `yaml:"version"` // prompt version used when the request sets no prompt_version
+}
+
+// ComplianceConfig controls the check of every call against the resolution steps of the knowledge base
+type ComplianceConfig struct {
+	Enabled  bool   `yaml:"enabled"`  // run the check when the request does not set check_compliance
+	Provider string `yaml:"provider"` // LLM provider used for the check, the request's provider when empty
+}
+
+// HistoryConfig controls the seller's previous stored calls added to the generation prompt
+type HistoryConfig struct {
+	Enabled     bool `yaml:"enabled"`      // include history when the request does not set include_history
+	MaxCalls    int  `yaml:"max_calls"`    // most recent stored calls considered
+	TokenBudget int  `yaml:"token_budget"` // estimated tokens the history block may use, older calls are dropped first
+}
+
+// ActionItemsConfig controls the action items tracked from the next steps of stored insights
+type ActionItemsConfig struct {
+	Enabled        bool `yaml:"enabled"`          // extract action items when insights are stored
+	DefaultDueDays int  `yaml:"default_due_days"` // days after the call an item is due when its next step names no deadline
+}
+
+// Alert types and severities that alert rules can match
+var (
+	AlertTypes      = []string{"churn_risk", "upsell", "executive_inefficiency", "process_failure"}
+	AlertSeverities = []string{"low", "medium", "high"}
+)
+
+// Notifier types of alerts.notifiers
+var NotifierTypes = []string{"webhook", "slack", "email"}
+
+// AlertsConfig routes the alerts of stored insights to notifiers
+type AlertsConfig struct {
+	Enabled      bool                  `yaml:"enabled"`
+	DedupeWindow time.Duration         `yaml:"dedupe_window"` // an alert type is sent once per seller and notifier within this window
+	MaxAttempts  int                   `yaml:"max_attempts"`  // delivery attempts before a delivery is marked failed
+	RetryDelay   time.Duration         `yaml:"retry_delay"`   // wait before the first retry, doubled after every failed attempt
+	PollInterval time.Duration         `yaml:"poll_interval"` // how often pending deliveries are looked for
+	Timeout      time.Duration         `yaml:"timeout"`       // per delivery attempt
+	Notifiers    []AlertNotifierConfig `yaml:"notifiers"`
+	Rules        []AlertRuleConfig     `yaml:"rules"`
+}
+
+// AlertNotifierConfig is one destination of alerts, referenced by name from the rules
+type AlertNotifierConfig struct {
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type"`    // one of NotifierTypes
+	URL     string            `yaml:"url"`     // webhook and slack: the URL posted to
+	Headers map[string]string `yaml:"headers"` // webhook: extra request headers, e.g. Authorization
+
+	SMTPHost string   `yaml:"smtp_host"` // email
+	SMTPPort int      `yaml:"smtp_port"`
+	Username string   `yaml:"username"` // PLAIN auth when set
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// AlertRuleConfig sends the alerts it matches to its notifiers; empty lists match everything
+type AlertRuleConfig struct {
+	Name          string   `yaml:"name"`
+	AlertTypes    []string `yaml:"alert_types"`    // AlertTypes
+	MinSeverity   string   `yaml:"min_severity"`   // one of AlertSeverities, every severity when empty
+	CustomerTypes []string `yaml:"customer_types"` // case-insensitive
+	Cities        []string `yaml:"cities"`         // case-insensitive
+	Notifiers     []string `yaml:"notifiers"`      // names of alerts.notifiers
+}
+
+// RedactionKinds are the personal data detectors that can be listed in redaction.detectors
+var RedactionKinds = []string{"email", "upi", "gstin", "pan", "ifsc", "card", "aadhaar", "phone", "pincode", "address"}
+
+// RedactionConfig chooses where transcripts go with personal data replaced by placeholders
+type RedactionConfig struct {
+	LLM         bool     `yaml:"llm"`          // prompts sent to the LLM provider
+	VectorDB    bool     `yaml:"vector_db"`    // text embedded for the sample call search
+	Logs        bool     `yaml:"logs"`         // transcripts written to the application logs
+	Detectors   []string `yaml:"detectors"`    // RedactionKinds to detect, all of them when empty
+	KeepMapping bool     `yaml:"keep_mapping"` // save placeholder -> value per request under mapping_dir
+	MappingDir  string   `yaml:"mapping_dir"`
+}
+
+// Defaults returns the configuration used for every value the file and environment leave empty.
+// Secrets have no defaults.
+func Defaults() Config {
+	return Config{
+		Server: ServerConfig{Port: "8080", MaxCallsPerRequest: 20, MaxUploadMB: 50},
+		LLM: LLMConfig{
+			Provider:    "gateway",
+			APIURL:      "https://imllm.intermesh.net/v1/chat/completions",
+			Model:       "google/gemini-2.5-flash-lite",
+			Temperature: 0.7,
+			Timeout:     30 * time.Second,
+
+			RepairAttempts: 2,
+		},
+		Gemini: GeminiConfig{Model: "gemini-2.5-flash"},
+		VectorDB: VectorDBConfig{
+			PineconeHost:   "https://insights-fv7quol.svc.aped-4627-b74a.pinecone.io",
+			Namespace:      "default",
+			EmbeddingURL:   "https://imllm.intermesh.net/v1/embeddings",
+			EmbeddingModel: "google/gemini-embedding-001",
+		},
+		Transcription: TranscriptionConfig{
+			Provider:        "media_service",
+			URL:             "http://34.47.186.170/transcribe",
+			StatusURL:       "http://34.47.186.170/transcribe/status/{media_id}",
+			ModID:           "LMS",
+			Timeout:         40 * time.Second,
+			DownloadTimeout: 15 * time.Second,
+			Workers:         4,
+			PollInterval:    2 * time.Second,
+			PollMaxInterval: 15 * time.Second,
+			PollTimeout:     2 * time.Minute,
+			Whisper: WhisperConfig{
+				URL:   "http://localhost:8081",
+				Model: "whisper-1",
+			},
+		},
+		Jobs: JobsConfig{
+			Dir:        "jobs",
+			Workers:    2,
+			QueueSize:  100,
+			MaxRetries: 5,
+			RetryDelay: time.Minute,
+			TTL:        24 * time.Hour,
+		},
+		Store: StoreConfig{Path: "insights.db"},
+		Metrics: MetricsConfig{
+			HoldGap:               20 * time.Second,
+			HoldAlert:             120 * time.Second,
+			SilenceAlert:          60 * time.Second,
+			MaxExecutiveTalkShare: 0.8,
+			MaxInterruptions:      3,
+		},
+		Redaction: RedactionConfig{
+			LLM:        true,
+			VectorDB:   true,
+			Logs:       true,
+			MappingDir: "redaction",
+		},
+		Language:    LanguageConfig{OutputLanguage: "en", TranslateTo: "en"},
+		Prompts:     PromptsConfig{Version: "v1"},
+		Compliance:  ComplianceConfig{Enabled: true},
+		History:     HistoryConfig{Enabled: true, MaxCalls: 5, TokenBudget: 1000},
+		ActionItems: ActionItemsConfig{Enabled: true, DefaultDueDays: 2},
+		Alerts: AlertsConfig{
+			Enabled:      true,
+			DedupeWindow: 24 * time.Hour,
+			MaxAttempts:  5,
+			RetryDelay:   30 * time.Second,
+			PollInterval: 10 * time.Second,
+			Timeout:      10 * time.Second,
+		},
+	}
+}
+
+var (
+	currentMu sync.RWMutex
+	current   *Config
+)
+
+// Get returns the configuration installed with Set, or the defaults when nothing was loaded yet
+func Get() *Config {
+	currentMu.RLock()
+	defer currentMu.RUnlock()
+	if current == nil {
+		defaults := Defaults()
+		return &defaults
+	}
+	return current
+}
+
+// Set installs cfg as the process-wide configuration
+func Set(cfg *Config) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = cfg
+}
+
+// Load reads the YAML file at path on top of the defaults, applies environment overrides and validates the result.
+// A missing file is only an error when required is true.
+func Load(path string, required bool) (*Config, error) {
+	cfg := Defaults()
+
+	fileBytes, readErr := os.ReadFile(path)
+	if readErr != nil {
+		if required || !os.IsNotExist(readErr) {
+			return nil, fmt.Errorf("failed to read config file %s: %w", path, readErr)
+		}
+	} else if unmarshalErr := yaml.Unmarshal(fileBytes, &cfg); unmarshalErr != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, unmarshalErr)
+	}
+
+	if envErr := cfg.applyEnv(os.LookupEnv); envErr != nil {
+		return nil, envErr
+	}
+	if cfg.VectorDB.EmbeddingAPIKey == "" {
+		cfg.VectorDB.EmbeddingAPIKey = cfg.LLM.APIKey
+	}
+	if validateErr := cfg.Validate(); validateErr != nil {
+		return nil, validateErr
+	}
+	return &cfg, nil
+}
+
+// applyEnv overrides config values from environment variables
+func (cfg *Config) applyEnv(lookup func(string) (string, bool)) error {
+	stringFields := map[string]*string{
+		"PORT":                   &cfg.Server.Port,
+		"LLM_PROVIDER":           &cfg.LLM.Provider,
+		"LLM_API_URL":            &cfg.LLM.APIURL,
+		"LLM_API_KEY":            &cfg.LLM.APIKey,
+		"LLM_MODEL":              &cfg.LLM.Model,
+		"GEMINI_API_KEY":         &cfg.Gemini.APIKey,
+		"GEMINI_MODEL":           &cfg.Gemini.Model,
+		"PINECONE_API_KEY":       &cfg.VectorDB.PineconeAPIKey,
+		"PINECONE_HOST":          &cfg.VectorDB.PineconeHost,
+		"PINECONE_NAMESPACE":     &cfg.VectorDB.Namespace,
+		"EMBEDDING_URL":          &cfg.VectorDB.EmbeddingURL,
+		"EMBEDDING_MODEL":        &cfg.VectorDB.EmbeddingModel,
+		"EMBEDDING_API_KEY":      &cfg.VectorDB.EmbeddingAPIKey,
+		"TRANSCRIBE_API_URL":     &cfg.Transcription.URL,
+		"TRANSCRIPTION_PROVIDER": &cfg.Transcription.Provider,
+		"TRANSCRIPTION_FAKE_DIR": &cfg.Transcription.FakeDir,
+		"WHISPER_URL":            &cfg.Transcription.Whisper.URL,
+		"WHISPER_API_KEY":        &cfg.Transcription.Whisper.APIKey,
+		"WHISPER_MODEL":          &cfg.Transcription.Whisper.Model,
+		"JOBS_DIR":               &cfg.Jobs.Dir,
+		"INSIGHTS_DB_PATH":       &cfg.Store.Path,
+		"REDACTION_MAPPING_DIR":  &cfg.Redaction.MappingDir,
+		"OUTPUT_LANGUAGE":        &cfg.Language.OutputLanguage,
+		"TRANSLATE_TO":           &cfg.Language.TranslateTo,
+		"TRANSLATION_PROVIDER":   &cfg.Language.TranslationProvider,
+		"PROMPTS_DIR":            &cfg.Prompts.Dir,
+		"PROMPT_VERSION":         &cfg.Prompts.Version,
+		"COMPLIANCE_PROVIDER":    &cfg.Compliance.Provider,
+	}
+	intFields := map[string]*int{
+		"LLM_REPAIR_ATTEMPTS":       &cfg.LLM.RepairAttempts,
+		"MAX_CALLS_PER_REQUEST":     &cfg.Server.MaxCallsPerRequest,
+		"MAX_UPLOAD_MB":             &cfg.Server.MaxUploadMB,
+		"JOBS_WORKERS":              &cfg.Jobs.Workers,
+		"TRANSCRIPTION_WORKERS":     &cfg.Transcription.Workers,
+		"METRICS_MAX_INTERRUPTIONS": &cfg.Metrics.MaxInterruptions,
+		"HISTORY_MAX_CALLS":         &cfg.History.MaxCalls,
+		"HISTORY_TOKEN_BUDGET":      &cfg.History.TokenBudget,
+		"ACTION_ITEMS_DUE_DAYS":     &cfg.ActionItems.DefaultDueDays,
+		"ALERTS_MAX_ATTEMPTS":       &cfg.Alerts.MaxAttempts,
+	}
+	durationFields := map[string]*time.Duration{
+		"LLM_TIMEOUT":             &cfg.LLM.Timeout,
+		"TRANSCRIBE_TIMEOUT":      &cfg.Transcription.Timeout,
+		"TRANSCRIBE_POLL_TIMEOUT": &cfg.Transcription.PollTimeout,
+		"JOBS_TTL":                &cfg.Jobs.TTL,
+		"METRICS_HOLD_GAP":        &cfg.Metrics.HoldGap,
+		"METRICS_HOLD_ALERT":      &cfg.Metrics.HoldAlert,
+		"METRICS_SILENCE_ALERT":   &cfg.Metrics.SilenceAlert,
+		"ALERTS_DEDUPE_WINDOW":    &cfg.Alerts.DedupeWindow,
+		"ALERTS_RETRY_DELAY":      &cfg.Alerts.RetryDelay,
+	}
+	floatFields := map[string]*float64{
+		"LLM_TEMPERATURE":                  &cfg.LLM.Temperature,
+		"METRICS_MAX_EXECUTIVE_TALK_SHARE": &cfg.Metrics.MaxExecutiveTalkShare,
+	}
+
+	boolFields := map[string]*bool{
+		"REDACT_LLM":             &cfg.Redaction.LLM,
+		"REDACT_VECTOR_DB":       &cfg.Redaction.VectorDB,
+		"REDACT_LOGS":            &cfg.Redaction.Logs,
+		"REDACTION_KEEP_MAPPING": &cfg.Redaction.KeepMapping,
+		"TRANSLATE_TRANSCRIPTS":  &cfg.Language.Translate,
+		"COMPLIANCE_CHECK":       &cfg.Compliance.Enabled,
+		"HISTORY_ENABLED":        &cfg.History.Enabled,
+		"ACTION_ITEMS_ENABLED":   &cfg.ActionItems.Enabled,
+		"ALERTS_ENABLED":         &cfg.Alerts.Enabled,
+	}
+
+	var errs []error
+	for name, field := range stringFields {
+		if value, ok := lookup(name); ok {
+			*field = value
+		}
+	}
+	for name, field := range intFields {
+		if value, ok := lookup(name); ok {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("env %s: invalid integer %q", name, value))
+				continue
+			}
+			*field = parsed
+		}
+	}
+	for name, field := range durationFields {
+		if value, ok := lookup(name); ok {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("env %s: invalid duration %q", name, value))
+				continue
+			}
+			*field = parsed
+		}
+	}
+	for name, field := range floatFields {
+		if value, ok := lookup(name); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("env %s: invalid number %q", name, value))
+				continue
+			}
+			*field = parsed
+		}
+	}
+	for name, field := range boolFields {
+		if value, ok := lookup(name); ok {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("env %s: invalid boolean %q", name, value))
+				continue
+			}
+			*field = parsed
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate reports every invalid or missing value at once
+func (cfg *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(cfg.Server.Port); err != nil || port <= 0 || port > 65535 {
+		add("server.port: %q is not a valid port", cfg.Server.Port)
+	}
+	if cfg.Server.MaxCallsPerRequest <= 0 {
+		add("server.max_calls_per_request: must be positive")
+	}
+	if cfg.Server.MaxUploadMB <= 0 {
+		add("server.max_upload_mb: must be positive")
+	}
+
+	switch cfg.LLM.Provider {
+	case "gateway":
+		if cfg.LLM.APIKey == "" {
+			add("llm.api_key: required for the gateway provider (or set LLM_API_KEY)")
+		}
+	case "gemini":
+		if cfg.Gemini.APIKey == "" {
+			add("gemini.api_key: required for the gemini provider (or set GEMINI_API_KEY)")
+		}
+	default:
+		add("llm.provider: %q must be one of gateway, gemini", cfg.LLM.Provider)
+	}
+	if !isHTTPURL(cfg.LLM.APIURL) {
+		add("llm.api_url: %q is not an http(s) URL", cfg.LLM.APIURL)
+	}
+	if cfg.LLM.Model == "" {
+		add("llm.model: required")
+	}
+	if cfg.LLM.Timeout <= 0 {
+		add("llm.timeout: must be positive")
+	}
+	if cfg.LLM.RepairAttempts < 0 {
+		add("llm.repair_attempts: must not be negative")
+	}
+	if cfg.Gemini.Model == "" {
+		add("gemini.model: required")
+	}
+
+	if cfg.VectorDB.PineconeAPIKey == "" {
+		add("vector_db.pinecone_api_key: required (or set PINECONE_API_KEY)")
+	}
+	if !isHTTPURL(cfg.VectorDB.PineconeHost) {
+		add("vector_db.pinecone_host: %q is not an http(s) URL", cfg.VectorDB.PineconeHost)
+	}
+	if !isHTTPURL(cfg.VectorDB.EmbeddingURL) {
+		add("vector_db.embedding_url: %q is not an http(s) URL", cfg.VectorDB.EmbeddingURL)
+	}
+	if cfg.VectorDB.EmbeddingAPIKey == "" {
+		add("vector_db.embedding_api_key: required (or set EMBEDDING_API_KEY / LLM_API_KEY)")
+	}
+
+	switch cfg.Transcription.Provider {
+	case "media_service":
+		if !isHTTPURL(cfg.Transcription.URL) {
+			add("transcription.url: %q is not an http(s) URL", cfg.Transcription.URL)
+		}
+		if !isHTTPURL(strings.ReplaceAll(cfg.Transcription.StatusURL, "{media_id}", "id")) {
+			add("transcription.status_url: %q is not an http(s) URL", cfg.Transcription.StatusURL)
+		}
+		if cfg.Transcription.PollInterval <= 0 || cfg.Transcription.PollMaxInterval < cfg.Transcription.PollInterval {
+			add("transcription.poll_interval: must be positive and not above poll_max_interval")
+		}
+		if cfg.Transcription.PollTimeout < 0 {
+			add("transcription.poll_timeout: must not be negative")
+		}
+	case "whisper":
+		if !isHTTPURL(cfg.Transcription.Whisper.URL) {
+			add("transcription.whisper.url: %q is not an http(s) URL", cfg.Transcription.Whisper.URL)
+		}
+		if cfg.Transcription.Whisper.Model == "" {
+			add("transcription.whisper.model: required")
+		}
+	case "fake":
+		if cfg.Transcription.FakeDir == "" {
+			add("transcription.fake_dir: required for the fake provider (or set TRANSCRIPTION_FAKE_DIR)")
+		}
+	default:
+		add("transcription.provider: %q must be one of media_service, whisper, fake", cfg.Transcription.Provider)
+	}
+	if cfg.Transcription.Timeout <= 0 {
+		add("transcription.timeout: must be positive")
+	}
+	if cfg.Transcription.DownloadTimeout <= 0 {
+		add("transcription.download_timeout: must be positive")
+	}
+
+	if cfg.Transcription.Workers <= 0 {
+		add("transcription.workers: must be positive")
+	}
+
+	if cfg.Jobs.Dir == "" {
+		add("jobs.dir: required")
+	}
+	if cfg.Jobs.Workers <= 0 {
+		add("jobs.workers: must be positive")
+	}
+	if cfg.Jobs.QueueSize <= 0 {
+		add("jobs.queue_size: must be positive")
+	}
+	if cfg.Jobs.MaxRetries < 0 {
+		add("jobs.max_retries: must not be negative")
+	}
+	if cfg.Jobs.MaxRetries > 0 && cfg.Jobs.RetryDelay <= 0 {
+		add("jobs.retry_delay: must be positive when max_retries is set")
+	}
+	if cfg.Jobs.TTL < 0 {
+		add("jobs.ttl: must not be negative")
+	}
+
+	if cfg.Store.Path == "" {
+		add("store.path: required")
+	}
+
+	if cfg.Metrics.HoldGap <= 0 {
+		add("metrics.hold_gap: must be positive")
+	}
+	if cfg.Metrics.HoldAlert <= 0 || cfg.Metrics.SilenceAlert <= 0 {
+		add("metrics.hold_alert and metrics.silence_alert: must be positive")
+	}
+	if cfg.Metrics.MaxExecutiveTalkShare <= 0 || cfg.Metrics.MaxExecutiveTalkShare > 1 {
+		add("metrics.max_executive_talk_share: must be above 0 and at most 1")
+	}
+	if cfg.Metrics.MaxInterruptions < 0 {
+		add("metrics.max_interruptions: must not be negative")
+	}
+
+	for _, kind := range cfg.Redaction.Detectors {
+		known := false
+		for _, candidate := range RedactionKinds {
+			known = known || strings.EqualFold(candidate, kind)
+		}
+		if !known {
+			add("redaction.detectors: %q must be one of %s", kind, strings.Join(RedactionKinds, ", "))
+		}
+	}
+	if cfg.Redaction.KeepMapping && cfg.Redaction.MappingDir == "" {
+		add("redaction.mapping_dir: required when keep_mapping is set")
+	}
+
+	if cfg.Language.OutputLanguage == "" {
+		add("language.output_language: required")
+	}
+	if cfg.Language.Translate && cfg.Language.TranslateTo == "" {
+		add("language.translate_to: required when translate is set")
+	}
+	switch cfg.Language.TranslationProvider {
+	case "", "gateway", "gemini":
+	default:
+		add("language.translation_provider: %q must be one of gateway, gemini", cfg.Language.TranslationProvider)
+	}
+
+	if cfg.Prompts.Version == "" {
+		add("prompts.version: required")
+	}
+
+	switch cfg.Compliance.Provider {
+	case "", "gateway", "gemini":
+	default:
+		add("compliance.provider: %q must be one of gateway, gemini", cfg.Compliance.Provider)
+	}
+
+	if cfg.History.MaxCalls < 0 {
+		add("history.max_calls: must not be negative")
+	}
+	if cfg.History.Enabled && cfg.History.TokenBudget <= 0 {
+		add("history.token_budget: must be positive when history is enabled")
+	}
+
+	if cfg.ActionItems.DefaultDueDays < 0 {
+		add("action_items.default_due_days: must not be negative")
+	}
+
+	cfg.Alerts.validate(add)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// validate checks the alert settings, the notifiers and the rules that reference them
+func (alerts AlertsConfig) validate(add func(format string, args ...any)) {
+	if alerts.DedupeWindow < 0 {
+		add("alerts.dedupe_window: must not be negative")
+	}
+	if alerts.MaxAttempts <= 0 {
+		add("alerts.max_attempts: must be positive")
+	}
+	if alerts.RetryDelay <= 0 || alerts.PollInterval <= 0 || alerts.Timeout <= 0 {
+		add("alerts.retry_delay, alerts.poll_interval and alerts.timeout: must be positive")
+	}
+
+	notifierNames := map[string]bool{}
+	for i, notifier := range alerts.Notifiers {
+		field := fmt.Sprintf("alerts.notifiers[%d]", i)
+		if notifier.Name == "" {
+			add("%s.name: required", field)
+		} else if notifierNames[notifier.Name] {
+			add("%s.name: %q is used by another notifier", field, notifier.Name)
+		}
+		notifierNames[notifier.Name] = true
+
+		switch notifier.Type {
+		case "webhook", "slack":
+			if !isHTTPURL(notifier.URL) {
+				add("%s.url: %q is not an http(s) URL", field, notifier.URL)
+			}
+		case "email":
+			if notifier.SMTPHost == "" || notifier.SMTPPort <= 0 || notifier.SMTPPort > 65535 {
+				add("%s: smtp_host and a valid smtp_port are required for email", field)
+			}
+			if notifier.From == "" || len(notifier.To) == 0 {
+				add("%s: from and to are required for email", field)
+			}
+		default:
+			add("%s.type: %q must be one of %s", field, notifier.Type, strings.Join(NotifierTypes, ", "))
+		}
+	}
+
+	for i, rule := range alerts.Rules {
+		field := fmt.Sprintf("alerts.rules[%d]", i)
+		if rule.Name == "" {
+			add("%s.name: required", field)
+		}
+		for _, alertType := range rule.AlertTypes {
+			if !contains(AlertTypes, alertType) {
+				add("%s.alert_types: %q must be one of %s", field, alertType, strings.Join(AlertTypes, ", "))
+			}
+		}
+		if rule.MinSeverity != "" && !contains(AlertSeverities, rule.MinSeverity) {
+			add("%s.min_severity: %q must be one of %s", field, rule.MinSeverity, strings.Join(AlertSeverities, ", "))
+		}
+		if len(rule.Notifiers) == 0 {
+			add("%s.notifiers: at least one notifier is required", field)
+		}
+		for _, name := range rule.Notifiers {
+			if !notifierNames[name] {
+				add("%s.notifiers: %q is not a configured notifier", field, name)
+			}
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isHTTPURL(raw string) bool {
+	parsed, err := url.ParseRequestURI(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -1,7 +1,13 @@
 package handler
 
 import (
+	actionItemController "voice-hack-backend/modules/tripPlanner/controller/actionItemController"
+	alertController "voice-hack-backend/modules/tripPlanner/controller/alertController"
+	executiveController "voice-hack-backend/modules/tripPlanner/controller/executiveController"
 	insightsGenerateController "voice-hack-backend/modules/tripPlanner/controller/insightsGenerateController"
+	insightsJobController "voice-hack-backend/modules/tripPlanner/controller/insightsJobController"
+	knowledgeBaseController "voice-hack-backend/modules/tripPlanner/controller/knowledgeBaseController"
+	sellerController "voice-hack-backend/modules/tripPlanner/controller/sellerController"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,5 +22,35 @@ func RouteRequests(ginServer *gin.Engine) {
 	apiGroup.POST("/final/", insightsGenerateController.FinalSummaryGenerate)
 	apiGroup.GET("/final", insightsGenerateController.FinalSummaryGenerate)
 	apiGroup.GET("/final/", insightsGenerateController.FinalSummaryGenerate)
+	apiGroup.POST("/jobs", insightsJobController.CreateInsightsJob)
+	apiGroup.POST("/jobs/", insightsJobController.CreateInsightsJob)
+	apiGroup.GET("/jobs/:id", insightsJobController.GetInsightsJob)
 
+	knowledgeBaseGroup := ginServer.Group("knowledge-base")
+	knowledgeBaseGroup.GET("/concerns", knowledgeBaseController.ListConcernCategories)
+	knowledgeBaseGroup.POST("/concerns", knowledgeBaseController.CreateConcernCategory)
+	knowledgeBaseGroup.GET("/concerns/:code", knowledgeBaseController.GetConcernCategory)
+	knowledgeBaseGroup.PUT("/concerns/:code", knowledgeBaseController.UpdateConcernCategory)
+	knowledgeBaseGroup.DELETE("/concerns/:code", knowledgeBaseController.DeleteConcernCategory)
+
+	executiveGroup := ginServer.Group("executives")
+	executiveGroup.GET("/leaderboard", executiveController.GetLeaderboard)
+	executiveGroup.GET("/:executive_id/scorecard", executiveController.GetScorecard)
+	executiveGroup.GET("/compliance", executiveController.ListCompliance)
+	executiveGroup.GET("/:executive_id/compliance", executiveController.GetCompliance)
+	executiveGroup.GET("/overdue-action-items", actionItemController.ListOverdue)
+	executiveGroup.GET("/:executive_id/overdue-action-items", actionItemController.GetOverdue)
+
+	sellerGroup := ginServer.Group("sellers")
+	sellerGroup.GET("/:glid/timeline", sellerController.GetTimeline)
+
+	actionItemGroup := ginServer.Group("action-items")
+	actionItemGroup.GET("", actionItemController.ListActionItems)
+	actionItemGroup.GET("/:id", actionItemController.GetActionItem)
+	actionItemGroup.PATCH("/:id", actionItemController.UpdateActionItem)
+
+	alertGroup := ginServer.Group("alerts")
+	alertGroup.GET("/deliveries", alertController.ListDeliveries)
+	alertGroup.GET("/deliveries/:id", alertController.GetDelivery)
+	alertGroup.POST("/deliveries/:id/retry", alertController.RetryDelivery)
 }
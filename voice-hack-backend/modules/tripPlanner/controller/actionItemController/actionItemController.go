@@ -0,0 +1,94 @@
+package actionItemController
+
+import (
+	responseHelper "voice-hack-backend/modules/tripPlanner/controller/responseHelper"
+	insightsGenerateModel "voice-hack-backend/modules/tripPlanner/model/insightsGenerateModel"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListActionItems returns the action items matching the query, earliest due first
+func ListActionItems(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.ActionItemApiResponse{}
+	var query insightsGenerateModel.ActionItemQuery
+	if bindErr := ginCtx.ShouldBindQuery(&query); bindErr != nil {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, bindErr.Error(), nil)
+		return
+	}
+	if fieldErrors := insightsGenerateModel.ValidateActionItemQuery(query); len(fieldErrors) > 0 {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, "invalid request", fieldErrors)
+		return
+	}
+	items, loadErr := insightsGenerateModel.ListActionItems(ginCtx, query)
+	if loadErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, loadErr)
+		return
+	}
+	apiResponse.ActionItems = items
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
+
+// GetActionItem returns the action item named in the path
+func GetActionItem(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.ActionItemApiResponse{}
+	id, ok := responseHelper.BindID(ginCtx, &apiResponse)
+	if !ok {
+		return
+	}
+	item, getErr := insightsGenerateModel.GetActionItem(ginCtx, id)
+	if getErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, getErr)
+		return
+	}
+	apiResponse.ActionItem = &item
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
+
+// UpdateActionItem changes the status, due date, owner or description of the action item named in the path
+func UpdateActionItem(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.ActionItemApiResponse{}
+	id, ok := responseHelper.BindID(ginCtx, &apiResponse)
+	if !ok {
+		return
+	}
+	var update insightsGenerateModel.ActionItemUpdate
+	if bindErr := ginCtx.ShouldBindJSON(&update); bindErr != nil {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, bindErr.Error(), insightsGenerateModel.BindFieldErrors(bindErr))
+		return
+	}
+	if fieldErrors := insightsGenerateModel.ValidateActionItemUpdate(update); len(fieldErrors) > 0 {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, "invalid request", fieldErrors)
+		return
+	}
+	item, updateErr := insightsGenerateModel.UpdateActionItem(ginCtx, id, update)
+	if updateErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, updateErr)
+		return
+	}
+	apiResponse.ActionItem = &item
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
+
+// ListOverdue returns the overdue action items of every executive, the executive with most overdue items first
+func ListOverdue(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.ActionItemApiResponse{}
+	groups, loadErr := insightsGenerateModel.ListOverdueActionItems(ginCtx)
+	if loadErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, loadErr)
+		return
+	}
+	apiResponse.Executives = groups
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
+
+// GetOverdue returns the overdue action items of the executive named in the path
+func GetOverdue(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.ActionItemApiResponse{}
+	group, loadErr := insightsGenerateModel.GetOverdueActionItems(ginCtx, ginCtx.Param("executive_id"))
+	if loadErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, loadErr)
+		return
+	}
+	apiResponse.Overdue = &group
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
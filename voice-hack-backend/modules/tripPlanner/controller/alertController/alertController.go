@@ -0,0 +1,61 @@
+package alertController
+
+import (
+	responseHelper "voice-hack-backend/modules/tripPlanner/controller/responseHelper"
+	insightsGenerateModel "voice-hack-backend/modules/tripPlanner/model/insightsGenerateModel"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListDeliveries returns the alert deliveries matching the query, newest first
+func ListDeliveries(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.AlertApiResponse{}
+	var query insightsGenerateModel.AlertDeliveryQuery
+	if bindErr := ginCtx.ShouldBindQuery(&query); bindErr != nil {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, bindErr.Error(), nil)
+		return
+	}
+	if fieldErrors := insightsGenerateModel.ValidateAlertDeliveryQuery(query); len(fieldErrors) > 0 {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, "invalid request", fieldErrors)
+		return
+	}
+	deliveries, loadErr := insightsGenerateModel.ListAlertDeliveries(ginCtx, query)
+	if loadErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, loadErr)
+		return
+	}
+	apiResponse.Deliveries = deliveries
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
+
+// GetDelivery returns the alert delivery named in the path with its attempts
+func GetDelivery(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.AlertApiResponse{}
+	id, ok := responseHelper.BindID(ginCtx, &apiResponse)
+	if !ok {
+		return
+	}
+	delivery, getErr := insightsGenerateModel.GetAlertDelivery(ginCtx, id)
+	if getErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, getErr)
+		return
+	}
+	apiResponse.Delivery = &delivery
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
+
+// RetryDelivery queues the failed alert delivery named in the path again
+func RetryDelivery(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.AlertApiResponse{}
+	id, ok := responseHelper.BindID(ginCtx, &apiResponse)
+	if !ok {
+		return
+	}
+	delivery, retryErr := insightsGenerateModel.RetryAlertDelivery(ginCtx, id)
+	if retryErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, retryErr)
+		return
+	}
+	apiResponse.Delivery = &delivery
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
@@ -0,0 +1,87 @@
+package executiveController
+
+import (
+	responseHelper "voice-hack-backend/modules/tripPlanner/controller/responseHelper"
+	insightsGenerateModel "voice-hack-backend/modules/tripPlanner/model/insightsGenerateModel"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetScorecard returns the performance scorecard of the executive named in the path
+func GetScorecard(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.ExecutiveApiResponse{}
+	query, ok := bindQuery(ginCtx, &apiResponse)
+	if !ok {
+		return
+	}
+	scorecard, loadErr := insightsGenerateModel.GetExecutiveScorecard(ginCtx, query)
+	if loadErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, loadErr)
+		return
+	}
+	apiResponse.Scorecard = &scorecard
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
+
+// GetLeaderboard ranks the executives of the team
+func GetLeaderboard(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.ExecutiveApiResponse{}
+	query, ok := bindQuery(ginCtx, &apiResponse)
+	if !ok {
+		return
+	}
+	leaderboard, loadErr := insightsGenerateModel.GetLeaderboard(ginCtx, query)
+	if loadErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, loadErr)
+		return
+	}
+	apiResponse.Leaderboard = leaderboard
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
+
+// ListCompliance returns the resolution compliance of every executive with checked calls
+func ListCompliance(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.ExecutiveApiResponse{}
+	query, ok := bindQuery(ginCtx, &apiResponse)
+	if !ok {
+		return
+	}
+	summaries, loadErr := insightsGenerateModel.ExecutiveCompliance(ginCtx, query)
+	if loadErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, loadErr)
+		return
+	}
+	apiResponse.Executives = summaries
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
+
+// GetCompliance returns the resolution compliance of the executive named in the path
+func GetCompliance(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.ExecutiveApiResponse{}
+	query, ok := bindQuery(ginCtx, &apiResponse)
+	if !ok {
+		return
+	}
+	summaries, loadErr := insightsGenerateModel.ExecutiveCompliance(ginCtx, query)
+	if loadErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, loadErr)
+		return
+	}
+	apiResponse.Compliance = &summaries[0]
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
+
+// bindQuery reads and validates the query and the executive of the path, answering with a 400 when it is invalid
+func bindQuery(ginCtx *gin.Context, apiResponse *insightsGenerateModel.ExecutiveApiResponse) (insightsGenerateModel.ExecutiveQuery, bool) {
+	var query insightsGenerateModel.ExecutiveQuery
+	if bindErr := ginCtx.ShouldBindQuery(&query); bindErr != nil {
+		responseHelper.ReturnBadRequest(ginCtx, apiResponse, bindErr.Error(), nil)
+		return query, false
+	}
+	query.ExecutiveID = ginCtx.Param("executive_id")
+	if fieldErrors := insightsGenerateModel.ValidateExecutiveQuery(query); len(fieldErrors) > 0 {
+		responseHelper.ReturnBadRequest(ginCtx, apiResponse, "invalid request", fieldErrors)
+		return query, false
+	}
+	return query, true
+}
@@ -1,15 +1,18 @@
 package insightsGenerateController
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"voice-hack-backend/config"
+	responseHelper "voice-hack-backend/modules/tripPlanner/controller/responseHelper"
 	insightsGenerateModel "voice-hack-backend/modules/tripPlanner/model/insightsGenerateModel"
-	urlMedia "voice-hack-backend/utilities/urlMedia"
 
 	"github.com/gin-gonic/gin"
 )
 
-
 func FinalSummaryGenerate(ginCtx *gin.Context) {
 	// Bind Input
 	apiInputParam, bindErr := BindInputParams(ginCtx)
@@ -24,17 +27,26 @@ func FinalSummaryGenerate(ginCtx *gin.Context) {
 		apiResponse.Code = http.StatusBadRequest
 		apiResponse.Status = "Failure"
 		apiResponse.Error = bindErr.Error()
+		apiResponse.Errors = insightsGenerateModel.BindFieldErrors(bindErr)
+		ReturnApiResponse(ginCtx, http.StatusBadRequest, apiResponse)
+		return
+	}
+	if fieldErrors := insightsGenerateModel.ValidateFinalSummaryInput(apiInputParam); len(fieldErrors) > 0 {
+		apiResponse.Code = http.StatusBadRequest
+		apiResponse.Status = "Failure"
+		apiResponse.Error = "invalid request"
+		apiResponse.Errors = fieldErrors
 		ReturnApiResponse(ginCtx, http.StatusBadRequest, apiResponse)
 		return
 	}
 
-	// Generate final summary from stored JSON
-	resp, respErr := insightsGenerateModel.FinalSummary(apiInputParam)
+	// Generate final summary from the stored insights matching the filters
+	resp, respErr := insightsGenerateModel.FinalSummary(ginCtx, apiInputParam)
 	if respErr != nil {
-		apiResponse.Code = http.StatusInternalServerError
+		apiResponse.Code = responseHelper.ErrorStatus(respErr)
 		apiResponse.Status = "Failure"
 		apiResponse.Error = respErr.Error()
-		ReturnApiResponse(ginCtx, http.StatusInternalServerError, apiResponse)
+		ReturnApiResponse(ginCtx, apiResponse.Code, apiResponse)
 		return
 	}
 
@@ -48,7 +60,7 @@ func FinalSummaryGenerate(ginCtx *gin.Context) {
 	}
 
 	// Prepare response
-	apiResponse.Response.Locations = []insightsGenerateModel.Insights{*resp}
+	apiResponse.Response = *resp
 	apiResponse.Code = http.StatusOK
 	apiResponse.Status = "Success"
 
@@ -66,49 +78,28 @@ func FindDestination(ginCtx *gin.Context) {
 		apiResponse.Code = http.StatusBadRequest
 		apiResponse.Status = "Failure"
 		apiResponse.Error = bindErr.Error()
+		apiResponse.Errors = insightsGenerateModel.BindFieldErrors(bindErr)
 		ReturnApiResponse(ginCtx, http.StatusBadRequest, apiResponse)
 		return
 	}
-	// Call URL Media to get text from call recording URL
-	apiInputParam.TrascriptionURLTxt = make([]string, len(apiInputParam.CallData))
-
-	for index, callDate := range apiInputParam.CallData {
-		setInputParam := urlMedia.SetInputParamForTranscribeAPI(callDate.CallRecordingURL, apiInputParam.ExecutiveID, fmt.Sprint(apiInputParam.Glid))
-		fmt.Println(setInputParam)
-		getTextFromCallURl, err := urlMedia.CallTranscribeAPI(setInputParam)
-		if err != "" {
-			apiResponse.Code = http.StatusInternalServerError
-			apiResponse.Status = "Failure"
-			apiResponse.Error = err
-			ReturnApiResponse(ginCtx, http.StatusBadRequest, apiResponse)
-			return
-		}
-		fmt.Print(getTextFromCallURl.TranscriptionURL)
-		text, errors := urlMedia.GetTextFromURL(getTextFromCallURl.TranscriptionURL)
-
-		if errors != nil {
-			apiResponse.Code = http.StatusInternalServerError
-			apiResponse.Status = "Failure"
-			apiResponse.Error = "GettextFrom url failed" + errors.Error()
-			ReturnApiResponse(ginCtx, http.StatusBadRequest, apiResponse)
-			return
-		}
-		apiInputParam.TrascriptionURLTxt[index] = text
-
-		// fmt.Print(text)
+	if fieldErrors := insightsGenerateModel.ValidateGenerateInput(apiInputParam); len(fieldErrors) > 0 {
+		apiResponse.Code = http.StatusBadRequest
+		apiResponse.Status = "Failure"
+		apiResponse.Error = "invalid request"
+		apiResponse.Errors = fieldErrors
+		ReturnApiResponse(ginCtx, http.StatusBadRequest, apiResponse)
+		return
 	}
-
-	userQuery := insightsGenerateModel.GenerateUserQuery(apiInputParam)
-	// resp, respErr := insightsGenerateModel.GenerateInsights(ginCtx, userQuery, apiInputParam)
-	resp, respErr := insightsGenerateModel.GenerateInsightsFromLLM(ginCtx, userQuery, apiInputParam)
+	// Transcribe, retrieve samples, generate and store in one go
+	pipelineInput, resp, respErr := insightsGenerateModel.RunInsightsPipeline(ginCtx, apiInputParam, nil)
+	apiInputParam = pipelineInput
 	if respErr != nil {
-		apiResponse.Code = http.StatusInternalServerError
-		apiResponse.Status = "Success"
+		apiResponse.Code = responseHelper.ErrorStatus(respErr)
+		apiResponse.Status = "Failure"
 		apiResponse.Error = respErr.Error()
-		ReturnApiResponse(ginCtx, http.StatusBadRequest, apiResponse)
+		ReturnApiResponse(ginCtx, apiResponse.Code, apiResponse)
 		return
 	}
-	insightsGenerateModel.StoreInsightsToJSON(resp.Locations)
 
 	apiResponse.Code = http.StatusOK
 	apiResponse.Status = "Success"
@@ -116,9 +107,49 @@ func FindDestination(ginCtx *gin.Context) {
 	ReturnApiResponse(ginCtx, http.StatusOK, apiResponse)
 }
 
+// BindInputParams reads the request from a JSON body, or from a multipart form whose "payload"
+// part holds the JSON and whose other parts are the audio files named by call_data[].audio_file
 func BindInputParams(ginCtx *gin.Context) (InputParams insightsGenerateModel.ApiInputParams, err error) {
-	bindErr := ginCtx.ShouldBindBodyWithJSON(&InputParams)
-	return InputParams, bindErr
+	if ginCtx.ContentType() == "multipart/form-data" {
+		InputParams, err = bindMultipartParams(ginCtx)
+	} else {
+		err = ginCtx.ShouldBindBodyWithJSON(&InputParams)
+	}
+	return insightsGenerateModel.NormalizeCallSources(InputParams), err
+}
+
+func bindMultipartParams(ginCtx *gin.Context) (InputParams insightsGenerateModel.ApiInputParams, err error) {
+	maxBytes := int64(config.Get().Server.MaxUploadMB) << 20
+	ginCtx.Request.Body = http.MaxBytesReader(ginCtx.Writer, ginCtx.Request.Body, maxBytes)
+	if parseErr := ginCtx.Request.ParseMultipartForm(maxBytes); parseErr != nil {
+		return InputParams, fmt.Errorf("failed to read multipart form: %w", parseErr)
+	}
+
+	payload := ginCtx.Request.FormValue("payload")
+	if payload == "" {
+		return InputParams, errors.New(`multipart requests need the JSON request in a "payload" part`)
+	}
+	if unmarshalErr := json.Unmarshal([]byte(payload), &InputParams); unmarshalErr != nil {
+		return InputParams, unmarshalErr
+	}
+
+	for index, call := range InputParams.CallData {
+		if call.AudioFile == "" {
+			continue
+		}
+		file, header, fileErr := ginCtx.Request.FormFile(call.AudioFile)
+		if fileErr != nil {
+			continue // reported by the audio_file validation rule
+		}
+		audio, readErr := io.ReadAll(file)
+		file.Close()
+		if readErr != nil {
+			return InputParams, fmt.Errorf("failed to read uploaded file %q: %w", call.AudioFile, readErr)
+		}
+		InputParams.CallData[index].Audio = audio
+		InputParams.CallData[index].AudioFileName = header.Filename
+	}
+	return InputParams, nil
 }
 
 func ReturnApiResponse(ginCtx *gin.Context, apiCode int, apiResponse insightsGenerateModel.ApiResponse) {
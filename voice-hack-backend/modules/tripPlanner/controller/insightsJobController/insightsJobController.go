@@ -0,0 +1,49 @@
+package insightsJobController
+
+import (
+	"net/http"
+	insightsGenerateController "voice-hack-backend/modules/tripPlanner/controller/insightsGenerateController"
+	responseHelper "voice-hack-backend/modules/tripPlanner/controller/responseHelper"
+	insightsGenerateModel "voice-hack-backend/modules/tripPlanner/model/insightsGenerateModel"
+	insightsJobModel "voice-hack-backend/modules/tripPlanner/model/insightsJobModel"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateInsightsJob queues the /insights/generate pipeline and returns the job ID immediately
+func CreateInsightsJob(ginCtx *gin.Context) {
+	apiInputParam, bindErr := insightsGenerateController.BindInputParams(ginCtx)
+	apiResponse := insightsJobModel.JobApiResponse{}
+
+	if bindErr != nil {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, bindErr.Error(), insightsGenerateModel.BindFieldErrors(bindErr))
+		return
+	}
+	if fieldErrors := insightsGenerateModel.ValidateGenerateInput(apiInputParam); len(fieldErrors) > 0 {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, "invalid request", fieldErrors)
+		return
+	}
+
+	job, submitErr := insightsJobModel.Submit(apiInputParam)
+	if submitErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, submitErr)
+		return
+	}
+
+	apiResponse.Job = job
+	responseHelper.ReturnSuccessCode(ginCtx, &apiResponse, http.StatusAccepted)
+}
+
+// GetInsightsJob returns the job's progress and, once completed, its insights
+func GetInsightsJob(ginCtx *gin.Context) {
+	apiResponse := insightsJobModel.JobApiResponse{}
+
+	job, getErr := insightsJobModel.Get(ginCtx.Param("id"))
+	if getErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, getErr)
+		return
+	}
+
+	apiResponse.Job = job
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
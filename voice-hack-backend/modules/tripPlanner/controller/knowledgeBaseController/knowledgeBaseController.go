@@ -0,0 +1,81 @@
+package knowledgeBaseController
+
+import (
+	"net/http"
+	responseHelper "voice-hack-backend/modules/tripPlanner/controller/responseHelper"
+	insightsGenerateModel "voice-hack-backend/modules/tripPlanner/model/insightsGenerateModel"
+	"voice-hack-backend/utilities/insightStore"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListConcernCategories returns the knowledge base in prompt order
+func ListConcernCategories(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.ConcernCategoryApiResponse{}
+	apiResponse.Categories = insightsGenerateModel.KnowledgeBaseConcerns()
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
+
+// GetConcernCategory returns one category by code
+func GetConcernCategory(ginCtx *gin.Context) {
+	category, getErr := insightsGenerateModel.GetConcernCategory(ginCtx.Param("code"))
+	returnCategory(ginCtx, http.StatusOK, category, getErr)
+}
+
+// CreateConcernCategory adds a category, which the next generated prompt and enum include
+func CreateConcernCategory(ginCtx *gin.Context) {
+	var category insightStore.ConcernCategory
+	if !bindCategory(ginCtx, &category) {
+		return
+	}
+	created, createErr := insightsGenerateModel.CreateConcernCategory(ginCtx, category)
+	returnCategory(ginCtx, http.StatusCreated, created, createErr)
+}
+
+// UpdateConcernCategory replaces the category named in the path
+func UpdateConcernCategory(ginCtx *gin.Context) {
+	var category insightStore.ConcernCategory
+	if !bindCategory(ginCtx, &category) {
+		return
+	}
+	updated, updateErr := insightsGenerateModel.UpdateConcernCategory(ginCtx, ginCtx.Param("code"), category)
+	returnCategory(ginCtx, http.StatusOK, updated, updateErr)
+}
+
+// DeleteConcernCategory removes the category named in the path
+func DeleteConcernCategory(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.ConcernCategoryApiResponse{}
+	if deleteErr := insightsGenerateModel.DeleteConcernCategory(ginCtx, ginCtx.Param("code")); deleteErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, deleteErr)
+		return
+	}
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
+
+// bindCategory reads and validates the request body, answering with a 400 when it is invalid.
+// On update the code comes from the path.
+func bindCategory(ginCtx *gin.Context, category *insightStore.ConcernCategory) bool {
+	apiResponse := insightsGenerateModel.ConcernCategoryApiResponse{}
+	if bindErr := ginCtx.ShouldBindJSON(category); bindErr != nil {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, bindErr.Error(), insightsGenerateModel.BindFieldErrors(bindErr))
+		return false
+	}
+	if code := ginCtx.Param("code"); code != "" {
+		category.Code = code
+	}
+	if fieldErrors := insightsGenerateModel.ValidateConcernCategory(*category); len(fieldErrors) > 0 {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, "invalid request", fieldErrors)
+		return false
+	}
+	return true
+}
+
+func returnCategory(ginCtx *gin.Context, successCode int, category insightStore.ConcernCategory, err error) {
+	apiResponse := insightsGenerateModel.ConcernCategoryApiResponse{}
+	if err != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, err)
+		return
+	}
+	apiResponse.Category = &category
+	responseHelper.ReturnSuccessCode(ginCtx, &apiResponse, successCode)
+}
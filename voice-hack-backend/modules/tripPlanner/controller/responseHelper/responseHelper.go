@@ -0,0 +1,81 @@
+package responseHelper
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	insightsGenerateModel "voice-hack-backend/modules/tripPlanner/model/insightsGenerateModel"
+	insightsJobModel "voice-hack-backend/modules/tripPlanner/model/insightsJobModel"
+	"voice-hack-backend/utilities/insightStore"
+	urlMedia "voice-hack-backend/utilities/urlMedia"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response is an endpoint response embedding insightsGenerateModel.ApiStatus
+type Response interface {
+	Outcome() *insightsGenerateModel.ApiStatus
+}
+
+// BindID reads the id of the path, answering with a 400 when it is not a positive number
+func BindID(ginCtx *gin.Context, apiResponse Response) (int64, bool) {
+	id, parseErr := strconv.ParseInt(ginCtx.Param("id"), 10, 64)
+	if parseErr != nil || id <= 0 {
+		ReturnBadRequest(ginCtx, apiResponse, "invalid request", []insightsGenerateModel.FieldError{
+			{Field: "id", Reason: "must be a positive number, got " + strconv.Quote(ginCtx.Param("id"))},
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+func ReturnBadRequest(ginCtx *gin.Context, apiResponse Response, message string, fieldErrors []insightsGenerateModel.FieldError) {
+	status := apiResponse.Outcome()
+	status.Code = http.StatusBadRequest
+	status.Status = "Failure"
+	status.Error = message
+	status.Errors = fieldErrors
+	ginCtx.JSON(http.StatusBadRequest, apiResponse)
+}
+
+func ReturnError(ginCtx *gin.Context, apiResponse Response, err error) {
+	status := apiResponse.Outcome()
+	status.Code = ErrorStatus(err)
+	status.Status = "Failure"
+	status.Error = err.Error()
+	ginCtx.JSON(status.Code, apiResponse)
+}
+
+func ReturnSuccess(ginCtx *gin.Context, apiResponse Response) {
+	ReturnSuccessCode(ginCtx, apiResponse, http.StatusOK)
+}
+
+// ReturnSuccessCode answers with a success code other than 200, such as 201 for a created resource
+func ReturnSuccessCode(ginCtx *gin.Context, apiResponse Response, code int) {
+	status := apiResponse.Outcome()
+	status.Code = code
+	status.Status = "Success"
+	ginCtx.JSON(code, apiResponse)
+}
+
+// ErrorStatus maps a model error to its HTTP status:
+//   - 404 for an unknown record or job, or when no stored insight matches the filters
+//   - 409 for a concern code already taken or retrying a delivery that has not failed
+//   - 502 when the LLM kept returning invalid output
+//   - 503 when the job queue is full or a transcription is still processing, the request can be retried later
+//   - 500 otherwise
+func ErrorStatus(err error) int {
+	var outputErr *insightsGenerateModel.LLMOutputError
+	switch {
+	case errors.Is(err, insightStore.ErrNotFound), errors.Is(err, insightsJobModel.ErrJobNotFound),
+		errors.Is(err, insightsGenerateModel.ErrNoMatchingInsights):
+		return http.StatusNotFound
+	case errors.Is(err, insightStore.ErrExists), errors.Is(err, insightsGenerateModel.ErrNotRetryable):
+		return http.StatusConflict
+	case errors.As(err, &outputErr):
+		return http.StatusBadGateway
+	case errors.Is(err, insightsJobModel.ErrQueueFull), errors.Is(err, urlMedia.ErrTranscriptionProcessing):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
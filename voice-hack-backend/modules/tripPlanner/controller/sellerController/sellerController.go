@@ -0,0 +1,39 @@
+package sellerController
+
+import (
+	"strconv"
+	responseHelper "voice-hack-backend/modules/tripPlanner/controller/responseHelper"
+	insightsGenerateModel "voice-hack-backend/modules/tripPlanner/model/insightsGenerateModel"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTimeline returns every stored call of the seller named in the path, oldest first
+func GetTimeline(ginCtx *gin.Context) {
+	apiResponse := insightsGenerateModel.SellerApiResponse{}
+	var query insightsGenerateModel.SellerQuery
+	if bindErr := ginCtx.ShouldBindQuery(&query); bindErr != nil {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, bindErr.Error(), nil)
+		return
+	}
+	glid, parseErr := strconv.Atoi(ginCtx.Param("glid"))
+	if parseErr != nil {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, "invalid request", []insightsGenerateModel.FieldError{
+			{Field: "glid", Reason: "must be a number, got " + strconv.Quote(ginCtx.Param("glid"))},
+		})
+		return
+	}
+	query.Glid = glid
+	if fieldErrors := insightsGenerateModel.ValidateSellerQuery(query); len(fieldErrors) > 0 {
+		responseHelper.ReturnBadRequest(ginCtx, &apiResponse, "invalid request", fieldErrors)
+		return
+	}
+
+	timeline, loadErr := insightsGenerateModel.GetSellerTimeline(ginCtx, query)
+	if loadErr != nil {
+		responseHelper.ReturnError(ginCtx, &apiResponse, loadErr)
+		return
+	}
+	apiResponse.Timeline = &timeline
+	responseHelper.ReturnSuccess(ginCtx, &apiResponse)
+}
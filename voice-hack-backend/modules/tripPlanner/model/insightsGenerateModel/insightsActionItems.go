@@ -0,0 +1,323 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/insightStore"
+)
+
+// ActionItemOverdue is reported instead of open once the due date has passed; it is never stored
+const ActionItemOverdue = "overdue"
+
+// OwnerExecutive owns the next steps that name nobody, as the prompt asks executives to follow up
+const OwnerExecutive = "executive"
+
+// Deadlines written in a next step, in days after the call. Phrases match whole words only, and "a day",
+// "a week" or "a month" need a within, in or by in front so frequencies such as "twice a week" are not read as deadlines.
+var (
+	dueDaysPattern  = regexp.MustCompile(`(\d+)\s*(?:working\s+|business\s+)?days?\b`)
+	dueHoursPattern = regexp.MustCompile(`(\d+)\s*(?:hours?|hrs?)\b`)
+	dueWeeksPattern = regexp.MustCompile(`(\d+)\s*weeks?\b`)
+	duePhrases      = []struct {
+		pattern *regexp.Regexp
+		days    int
+	}{
+		{regexp.MustCompile(`\bday after tomorrow\b`), 2},
+		{regexp.MustCompile(`\btomorrow\b`), 1},
+		{regexp.MustCompile(`\bnext day\b`), 1},
+		{regexp.MustCompile(`\b(?:within|in|by)\s+(?:a|one)\s+day\b`), 1},
+		{regexp.MustCompile(`\btoday\b`), 0},
+		{regexp.MustCompile(`\bsame day\b`), 0},
+		{regexp.MustCompile(`\bimmediately\b`), 0},
+		{regexp.MustCompile(`\bnext week\b`), 7},
+		{regexp.MustCompile(`\b(?:within|in|by)\s+(?:a|one)\s+week\b`), 7},
+		{regexp.MustCompile(`\bnext month\b`), 30},
+		{regexp.MustCompile(`\b(?:within|in|by)\s+(?:a|one)\s+month\b`), 30},
+	}
+)
+
+// ActionItemQuery holds the filters of GET /action-items
+type ActionItemQuery struct {
+	Glid        int    `form:"glid"`
+	ExecutiveID string `form:"executive_id"`
+	Owner       string `form:"owner"`     // one of ownerPersonaValues
+	Status      string `form:"status"`    // open, done or overdue
+	FromDate    string `form:"from_date"` // due on or after, YYYY-MM-DD
+	ToDate      string `form:"to_date"`   // due on or before, YYYY-MM-DD
+	Limit       int    `form:"limit"`     // all when 0
+}
+
+// ActionItemUpdate is the body of PATCH /action-items/:id, nil fields are left unchanged
+type ActionItemUpdate struct {
+	Status      *string `json:"status"` // open or done
+	DueDate     *string `json:"due_date"`
+	Owner       *string `json:"owner"`
+	Description *string `json:"description"`
+}
+
+// ActionItem is a stored action item with its status as of today
+type ActionItem struct {
+	insightStore.ActionItem
+	DaysOverdue int `json:"days_overdue,omitempty"`
+}
+
+// OverdueActionItems are the overdue items of one executive, most overdue first
+type OverdueActionItems struct {
+	ExecutiveID   string       `json:"executive_id"`
+	Overdue       int          `json:"overdue"`
+	OldestDueDate string       `json:"oldest_due_date,omitempty"`
+	Items         []ActionItem `json:"items"`
+}
+
+// ActionItemApiResponse is returned by the /action-items and overdue action item endpoints
+type ActionItemApiResponse struct {
+	ApiStatus
+	ActionItem  *ActionItem          `json:"action_item,omitempty"`  // GET and PATCH /action-items/:id
+	ActionItems []ActionItem         `json:"action_items,omitempty"` // GET /action-items
+	Overdue     *OverdueActionItems  `json:"overdue,omitempty"`      // /executives/:executive_id/overdue-action-items
+	Executives  []OverdueActionItems `json:"executives,omitempty"`   // /executives/overdue-action-items, most overdue first
+}
+
+// ExtractActionItems turns the next steps of a stored call-level insight into open action items.
+// Typed next steps keep their owner; free-text ones are split on ";" and owned by the persona they start with.
+func ExtractActionItems(row insightStore.StoredInsight, insight Insights) []insightStore.ActionItem {
+	var items []insightStore.ActionItem
+	for _, step := range callNextSteps(insight) {
+		owner := step.Owner
+		if owner == "" {
+			owner = actionOwner(step.Action)
+		}
+		items = append(items, insightStore.ActionItem{
+			InsightID:   row.ID,
+			Glid:        row.Glid,
+			ExecutiveID: row.ExecutiveID,
+			Owner:       owner,
+			Description: step.Action,
+			CallDate:    row.CallDate,
+			DueDate:     actionDueDate(row, step.Due, step.Action),
+			Status:      insightStore.ActionItemOpen,
+		})
+	}
+	return items
+}
+
+// storeActionItems saves the action items of freshly stored rows
+func storeActionItems(ctx context.Context, repo insightStore.Repository, rows []insightStore.StoredInsight, insights []Insights) error {
+	var items []insightStore.ActionItem
+	for i, row := range rows {
+		items = append(items, ExtractActionItems(row, insights[i])...)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	_, err := repo.SaveActionItems(ctx, items)
+	return err
+}
+
+// actionOwner is the persona a free-text next step starts with, e.g. "Seller to share images" is the seller's
+func actionOwner(action string) string {
+	action = strings.ToLower(strings.TrimSpace(action))
+	for _, persona := range ownerPersonaValues {
+		if strings.HasPrefix(action, strings.ReplaceAll(persona, "_", " ")) {
+			return persona
+		}
+	}
+	return OwnerExecutive
+}
+
+// actionDueDate derives the due date of a next step from the call date: an explicit due date wins, then a
+// deadline written in due or in the action, then action_items.default_due_days. Calls without a date count
+// from the day they were stored.
+func actionDueDate(row insightStore.StoredInsight, due string, action string) string {
+	if dueDate, ok := insightStore.NormalizeCallDate(due); ok {
+		return dueDate
+	}
+	base, err := time.Parse("2006-01-02", row.CallDate)
+	if err != nil {
+		base = row.CreatedAt
+		if base.IsZero() {
+			base = time.Now()
+		}
+	}
+	days := config.Get().ActionItems.DefaultDueDays
+	for _, text := range []string{due, action} {
+		if offset, ok := dueOffset(strings.ToLower(text)); ok {
+			days = offset
+			break
+		}
+	}
+	return base.AddDate(0, 0, days).Format("2006-01-02")
+}
+
+// dueOffset reads a deadline such as "within 2 days", "in 48 hours" or "tomorrow" as days after the call
+func dueOffset(text string) (int, bool) {
+	if match := dueDaysPattern.FindStringSubmatch(text); match != nil {
+		days, _ := strconv.Atoi(match[1])
+		return days, true
+	}
+	if match := dueHoursPattern.FindStringSubmatch(text); match != nil {
+		hours, _ := strconv.Atoi(match[1])
+		return int(math.Ceil(float64(hours) / 24)), true
+	}
+	if match := dueWeeksPattern.FindStringSubmatch(text); match != nil {
+		weeks, _ := strconv.Atoi(match[1])
+		return weeks * 7, true
+	}
+	for _, due := range duePhrases {
+		if due.pattern.MatchString(text) {
+			return due.days, true
+		}
+	}
+	return 0, false
+}
+
+// ListActionItems returns the items matching query, earliest due first
+func ListActionItems(ctx context.Context, query ActionItemQuery) ([]ActionItem, error) {
+	repo, err := insightStore.Default()
+	if err != nil {
+		return nil, err
+	}
+	today := time.Now().Format("2006-01-02")
+	filter := insightStore.ActionItemFilter{
+		Glid:        query.Glid,
+		ExecutiveID: query.ExecutiveID,
+		Owner:       query.Owner,
+		Status:      query.Status,
+		Limit:       query.Limit,
+	}
+	filter.FromDate, _ = insightStore.NormalizeCallDate(query.FromDate)
+	filter.ToDate, _ = insightStore.NormalizeCallDate(query.ToDate)
+	switch query.Status {
+	case insightStore.ActionItemOpen:
+		// Open items past due are listed as overdue instead
+		if filter.FromDate < today {
+			filter.FromDate = today
+		}
+	case ActionItemOverdue:
+		filter.Status, filter.DueBefore = insightStore.ActionItemOpen, today
+	}
+	stored, err := repo.ListActionItems(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]ActionItem, 0, len(stored))
+	for _, item := range stored {
+		items = append(items, actionItemAsOf(item, today))
+	}
+	return items, nil
+}
+
+// GetActionItem returns one item, failing with insightStore.ErrNotFound for an unknown id
+func GetActionItem(ctx context.Context, id int64) (ActionItem, error) {
+	repo, err := insightStore.Default()
+	if err != nil {
+		return ActionItem{}, err
+	}
+	item, err := repo.GetActionItem(ctx, id)
+	if err != nil {
+		return ActionItem{}, err
+	}
+	return actionItemAsOf(item, time.Now().Format("2006-01-02")), nil
+}
+
+// UpdateActionItem applies the set fields of update to the item, recording when it was marked done
+func UpdateActionItem(ctx context.Context, id int64, update ActionItemUpdate) (ActionItem, error) {
+	repo, err := insightStore.Default()
+	if err != nil {
+		return ActionItem{}, err
+	}
+	item, err := repo.GetActionItem(ctx, id)
+	if err != nil {
+		return ActionItem{}, err
+	}
+	if update.Status != nil && *update.Status != item.Status {
+		item.Status = *update.Status
+		item.CompletedAt = nil
+		if item.Status == insightStore.ActionItemDone {
+			completedAt := time.Now().UTC()
+			item.CompletedAt = &completedAt
+		}
+	}
+	if update.DueDate != nil {
+		item.DueDate, _ = insightStore.NormalizeCallDate(*update.DueDate)
+	}
+	if update.Owner != nil {
+		item.Owner = *update.Owner
+	}
+	if update.Description != nil {
+		item.Description = strings.TrimSpace(*update.Description)
+	}
+	if err := repo.UpdateActionItem(ctx, item); err != nil {
+		return ActionItem{}, err
+	}
+	return GetActionItem(ctx, id)
+}
+
+// ListOverdueActionItems groups the overdue items by executive, the executive with most overdue items first
+func ListOverdueActionItems(ctx context.Context) ([]OverdueActionItems, error) {
+	items, err := ListActionItems(ctx, ActionItemQuery{Status: ActionItemOverdue})
+	if err != nil {
+		return nil, err
+	}
+	byExecutive := map[string]*OverdueActionItems{}
+	for _, item := range items {
+		if byExecutive[item.ExecutiveID] == nil {
+			byExecutive[item.ExecutiveID] = &OverdueActionItems{ExecutiveID: item.ExecutiveID}
+		}
+		byExecutive[item.ExecutiveID].add(item)
+	}
+	groups := make([]OverdueActionItems, 0, len(byExecutive))
+	for _, group := range byExecutive {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Overdue != groups[j].Overdue {
+			return groups[i].Overdue > groups[j].Overdue
+		}
+		return groups[i].ExecutiveID < groups[j].ExecutiveID
+	})
+	return groups, nil
+}
+
+// GetOverdueActionItems returns the overdue items of one executive, none when everything is on time
+func GetOverdueActionItems(ctx context.Context, executiveID string) (OverdueActionItems, error) {
+	group := OverdueActionItems{ExecutiveID: executiveID, Items: []ActionItem{}}
+	items, err := ListActionItems(ctx, ActionItemQuery{ExecutiveID: executiveID, Status: ActionItemOverdue})
+	if err != nil {
+		return group, err
+	}
+	for _, item := range items {
+		group.add(item)
+	}
+	return group, nil
+}
+
+// add appends an item listed earliest due first, so the first item holds the oldest due date
+func (group *OverdueActionItems) add(item ActionItem) {
+	if group.OldestDueDate == "" {
+		group.OldestDueDate = item.DueDate
+	}
+	group.Overdue++
+	group.Items = append(group.Items, item)
+}
+
+// actionItemAsOf reports an open item whose due date is before today as overdue
+func actionItemAsOf(item insightStore.ActionItem, today string) ActionItem {
+	view := ActionItem{ActionItem: item}
+	if item.Status != insightStore.ActionItemOpen || item.DueDate == "" || item.DueDate >= today {
+		return view
+	}
+	view.Status = ActionItemOverdue
+	dueDate, dueErr := time.Parse("2006-01-02", item.DueDate)
+	todayDate, todayErr := time.Parse("2006-01-02", today)
+	if dueErr == nil && todayErr == nil {
+		view.DaysOverdue = int(todayDate.Sub(dueDate).Hours() / 24)
+	}
+	return view
+}
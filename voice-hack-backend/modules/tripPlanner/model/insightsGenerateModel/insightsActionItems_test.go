@@ -0,0 +1,32 @@
+package insightsGenerateModel
+
+import "testing"
+
+func TestDueOffset(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		days int
+		ok   bool
+	}{
+		{"call back within 2 days", 2, true},
+		{"share the details in 48 hours", 2, true},
+		{"review in 3 weeks", 21, true},
+		{"follow up tomorrow", 1, true},
+		{"visit the day after tomorrow", 2, true},
+		{"send the invoice within a day", 1, true},
+		{"fix the listing in a week", 7, true},
+		{"renew by a month", 30, true},
+		{"check again next week", 7, true},
+		{"escalate immediately", 0, true},
+		{"post products once a day", 0, false},
+		{"call the seller twice a week", 0, false},
+		{"bill a monthly fee", 0, false},
+		{"explain how todays leads work", 0, false},
+		{"share a weekly report", 0, false},
+	} {
+		days, ok := dueOffset(tc.text)
+		if days != tc.days || ok != tc.ok {
+			t.Errorf("dueOffset(%q) = %d, %v, want %d, %v", tc.text, days, ok, tc.days, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"voice-hack-backend/config"
+	notifier "voice-hack-backend/utilities/alertNotifier"
+	"voice-hack-backend/utilities/insightStore"
+)
+
+// SeverityUnknown is given to free-text v1 alerts, which rules treat as medium
+const SeverityUnknown = "medium"
+
+// alertDispatchBatch caps the deliveries attempted per dispatcher round
+const alertDispatchBatch = 50
+
+// ErrNotRetryable is returned when retrying a delivery that is not failed
+var ErrNotRetryable = errors.New("only failed deliveries can be retried")
+
+// alertWake starts a dispatcher round without waiting for the next poll
+var alertWake = make(chan struct{}, 1)
+
+// RoutedAlert is one alert raised on a call, as matched against the rules
+type RoutedAlert struct {
+	Type        string // one of alertTypeValues
+	Severity    string // low, medium or high
+	Description string
+}
+
+// AlertDeliveryQuery holds the filters of GET /alerts/deliveries
+type AlertDeliveryQuery struct {
+	Glid     int    `form:"glid"`
+	Notifier string `form:"notifier"`
+	Status   string `form:"status"` // pending, sent, failed or suppressed
+	Limit    int    `form:"limit"`  // all when 0
+}
+
+// AlertApiResponse is returned by the /alerts endpoints
+type AlertApiResponse struct {
+	ApiStatus
+	Delivery   *insightStore.AlertDelivery  `json:"delivery,omitempty"`   // /alerts/deliveries/:id
+	Deliveries []insightStore.AlertDelivery `json:"deliveries,omitempty"` // /alerts/deliveries, newest first
+}
+
+// CallAlerts returns the alerts of a call-level insight: the typed alerts when present, otherwise the
+// categories of the free-text alert with SeverityUnknown
+func CallAlerts(insight Insights) []RoutedAlert {
+	var alerts []RoutedAlert
+	if insight.Structured != nil {
+		for _, alert := range insight.Structured.Alerts {
+			alerts = append(alerts, RoutedAlert{Type: alert.Type, Severity: alert.Severity, Description: alert.Description})
+		}
+		return alerts
+	}
+	for _, label := range ClassifyAlert(insight.Alert) {
+		for alertType, typeLabel := range alertTypeLabels {
+			if typeLabel == label {
+				alerts = append(alerts, RoutedAlert{Type: alertType, Severity: SeverityUnknown, Description: insight.Alert})
+			}
+		}
+	}
+	return alerts
+}
+
+// alertRuleMatches reports whether rule routes alert, raised on the call of row
+func alertRuleMatches(rule config.AlertRuleConfig, alert RoutedAlert, row insightStore.StoredInsight) bool {
+	if len(rule.AlertTypes) > 0 && !contains(rule.AlertTypes, alert.Type) {
+		return false
+	}
+	if rule.MinSeverity != "" && severityRank(alert.Severity) < severityRank(rule.MinSeverity) {
+		return false
+	}
+	if len(rule.CustomerTypes) > 0 && !containsFold(rule.CustomerTypes, row.CustomerType) {
+		return false
+	}
+	if len(rule.Cities) > 0 && !containsFold(rule.Cities, row.CustomerCity) {
+		return false
+	}
+	return true
+}
+
+// severityRank orders low < medium < high, unknown severities rank as medium
+func severityRank(severity string) int {
+	for rank, candidate := range config.AlertSeverities {
+		if candidate == severity {
+			return rank
+		}
+	}
+	return severityRank(SeverityUnknown)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(strings.TrimSpace(candidate), strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeAlerts matches the alerts of freshly stored rows against alerts.rules and queues one delivery per
+// alert and notifier. An alert type already sent to a notifier for the seller within alerts.dedupe_window,
+// or earlier in the same batch, is recorded as suppressed.
+func routeAlerts(ctx context.Context, repo insightStore.Repository, rows []insightStore.StoredInsight, insights []Insights) error {
+	cfg := config.Get().Alerts
+	if len(cfg.Rules) == 0 {
+		return nil
+	}
+	now := time.Now()
+	since := now.Add(-cfg.DedupeWindow)
+	queued := map[string]bool{}
+	var deliveries []insightStore.AlertDelivery
+	for i, row := range rows {
+		for _, alert := range CallAlerts(insights[i]) {
+			for _, rule := range cfg.Rules {
+				if !alertRuleMatches(rule, alert, row) {
+					continue
+				}
+				for _, notifierName := range rule.Notifiers {
+					dedupeKey := fmt.Sprintf("%d|%s|%s", row.Glid, alert.Type, notifierName)
+					delivery := insightStore.AlertDelivery{
+						InsightID:     row.ID,
+						Glid:          row.Glid,
+						ExecutiveID:   row.ExecutiveID,
+						CustomerType:  row.CustomerType,
+						CustomerCity:  row.CustomerCity,
+						CallDate:      row.CallDate,
+						Rule:          rule.Name,
+						Notifier:      notifierName,
+						AlertType:     alert.Type,
+						Severity:      alert.Severity,
+						Description:   alert.Description,
+						DedupeKey:     dedupeKey,
+						Status:        insightStore.DeliveryPending,
+						NextAttemptAt: &now,
+					}
+					duplicate := queued[dedupeKey]
+					if !duplicate && cfg.DedupeWindow > 0 {
+						sent, err := repo.AlertDeliveredSince(ctx, dedupeKey, since)
+						if err != nil {
+							return err
+						}
+						duplicate = sent
+					}
+					if duplicate {
+						delivery.Status, delivery.NextAttemptAt = insightStore.DeliverySuppressed, nil
+					}
+					queued[dedupeKey] = true
+					deliveries = append(deliveries, delivery)
+				}
+			}
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if _, err := repo.SaveAlertDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+	wakeAlertDispatcher()
+	return nil
+}
+
+// StartAlertDispatcher delivers pending alerts in the background, every alerts.poll_interval and
+// whenever new deliveries are queued. Deliveries still pending after a restart are picked up again.
+func StartAlertDispatcher(cfg config.AlertsConfig) {
+	go func() {
+		ticker := time.NewTicker(cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			if err := DispatchDueAlerts(context.Background()); err != nil {
+				fmt.Println("alert dispatch failed:", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-alertWake:
+			}
+		}
+	}()
+}
+
+func wakeAlertDispatcher() {
+	select {
+	case alertWake <- struct{}{}:
+	default:
+	}
+}
+
+// DispatchDueAlerts attempts every pending delivery whose next attempt is due
+func DispatchDueAlerts(ctx context.Context) error {
+	repo, err := insightStore.Default()
+	if err != nil {
+		return err
+	}
+	for {
+		due, err := repo.DueAlertDeliveries(ctx, time.Now(), alertDispatchBatch)
+		if err != nil {
+			return err
+		}
+		for _, delivery := range due {
+			if err := repo.UpdateAlertDelivery(ctx, attemptDelivery(ctx, delivery)); err != nil {
+				return err
+			}
+		}
+		if len(due) < alertDispatchBatch {
+			return nil
+		}
+	}
+}
+
+// attemptDelivery sends delivery once and returns it with the outcome: sent, pending again after
+// alerts.retry_delay doubled for every earlier attempt, or failed after alerts.max_attempts
+func attemptDelivery(ctx context.Context, delivery insightStore.AlertDelivery) insightStore.AlertDelivery {
+	cfg := config.Get().Alerts
+	delivery.Attempts++
+	err := sendDelivery(ctx, delivery, cfg.Timeout)
+	now := time.Now()
+	if err == nil {
+		delivery.Status, delivery.LastError, delivery.NextAttemptAt, delivery.SentAt = insightStore.DeliverySent, "", nil, &now
+		return delivery
+	}
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= cfg.MaxAttempts {
+		delivery.Status, delivery.NextAttemptAt = insightStore.DeliveryFailed, nil
+		return delivery
+	}
+	nextAttemptAt := now.Add(cfg.RetryDelay << (delivery.Attempts - 1))
+	delivery.NextAttemptAt = &nextAttemptAt
+	return delivery
+}
+
+func sendDelivery(ctx context.Context, delivery insightStore.AlertDelivery, timeout time.Duration) error {
+	target, err := notifier.Get(delivery.Notifier)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return target.Notify(ctx, notifier.Notification{
+		DeliveryID:   delivery.ID,
+		Rule:         delivery.Rule,
+		AlertType:    delivery.AlertType,
+		Severity:     delivery.Severity,
+		Description:  delivery.Description,
+		Glid:         delivery.Glid,
+		ExecutiveID:  delivery.ExecutiveID,
+		CustomerType: delivery.CustomerType,
+		CustomerCity: delivery.CustomerCity,
+		CallDate:     delivery.CallDate,
+		InsightID:    delivery.InsightID,
+	})
+}
+
+// ListAlertDeliveries returns the deliveries matching query, newest first
+func ListAlertDeliveries(ctx context.Context, query AlertDeliveryQuery) ([]insightStore.AlertDelivery, error) {
+	repo, err := insightStore.Default()
+	if err != nil {
+		return nil, err
+	}
+	return repo.ListAlertDeliveries(ctx, insightStore.AlertDeliveryFilter{
+		Glid:     query.Glid,
+		Notifier: query.Notifier,
+		Status:   query.Status,
+		Limit:    query.Limit,
+	})
+}
+
+// GetAlertDelivery returns one delivery, failing with insightStore.ErrNotFound for an unknown id
+func GetAlertDelivery(ctx context.Context, id int64) (insightStore.AlertDelivery, error) {
+	repo, err := insightStore.Default()
+	if err != nil {
+		return insightStore.AlertDelivery{}, err
+	}
+	return repo.GetAlertDelivery(ctx, id)
+}
+
+// RetryAlertDelivery queues a failed delivery again with a fresh set of attempts
+func RetryAlertDelivery(ctx context.Context, id int64) (insightStore.AlertDelivery, error) {
+	repo, err := insightStore.Default()
+	if err != nil {
+		return insightStore.AlertDelivery{}, err
+	}
+	delivery, err := repo.GetAlertDelivery(ctx, id)
+	if err != nil {
+		return delivery, err
+	}
+	if delivery.Status != insightStore.DeliveryFailed {
+		return delivery, fmt.Errorf("alert delivery %d is %s: %w", id, delivery.Status, ErrNotRetryable)
+	}
+	now := time.Now()
+	delivery.Status, delivery.Attempts, delivery.NextAttemptAt = insightStore.DeliveryPending, 0, &now
+	if err := repo.UpdateAlertDelivery(ctx, delivery); err != nil {
+		return delivery, err
+	}
+	wakeAlertDispatcher()
+	return repo.GetAlertDelivery(ctx, id)
+}
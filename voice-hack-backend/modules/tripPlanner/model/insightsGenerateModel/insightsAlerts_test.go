@@ -0,0 +1,272 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+	"voice-hack-backend/config"
+	notifier "voice-hack-backend/utilities/alertNotifier"
+	"voice-hack-backend/utilities/insightStore"
+)
+
+// useAlerts installs alerts as the configuration and a fresh store as the default repository
+func useAlerts(t *testing.T, alerts config.AlertsConfig) *insightStore.SQLiteRepository {
+	t.Helper()
+	cfg := config.Defaults()
+	cfg.Alerts = alerts
+	previous := config.Get()
+	config.Set(&cfg)
+	t.Cleanup(func() { config.Set(previous) })
+
+	store, err := insightStore.OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "insights.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	insightStore.SetDefault(store)
+	return store
+}
+
+// failingNotifier fails every notification
+type failingNotifier struct{ name string }
+
+func (n failingNotifier) Name() string { return n.name }
+
+func (n failingNotifier) Notify(context.Context, notifier.Notification) error {
+	return errors.New("stand-in is down")
+}
+
+func churnInsight(severity string) Insights {
+	return Insights{InsightType: "final", Structured: &InsightsV2{Alerts: []AlertV2{
+		{Type: AlertTypeChurnRisk, Severity: severity, Description: "Seller mentions moving to a competitor"},
+	}}}
+}
+
+func TestCallAlertsFallsBackToFreeText(t *testing.T) {
+	alerts := CallAlerts(Insights{Alert: "Seller threatens to cancel the subscription"})
+	if len(alerts) != 1 || alerts[0].Type != AlertTypeChurnRisk || alerts[0].Severity != SeverityUnknown {
+		t.Fatalf("CallAlerts = %+v, want one medium churn_risk alert", alerts)
+	}
+	if alerts := CallAlerts(Insights{Alert: "None"}); len(alerts) != 0 {
+		t.Errorf("CallAlerts(None) = %+v, want no alerts", alerts)
+	}
+	if alerts := CallAlerts(churnInsight("high")); len(alerts) != 1 || alerts[0].Severity != "high" {
+		t.Errorf("CallAlerts(v2) = %+v, want the typed alert", alerts)
+	}
+}
+
+func TestAlertRuleMatches(t *testing.T) {
+	row := insightStore.StoredInsight{CustomerType: "Existing", CustomerCity: "New Delhi"}
+	alert := RoutedAlert{Type: AlertTypeChurnRisk, Severity: "medium"}
+	tests := []struct {
+		name string
+		rule config.AlertRuleConfig
+		want bool
+	}{
+		{"empty rule matches everything", config.AlertRuleConfig{}, true},
+		{"other alert type", config.AlertRuleConfig{AlertTypes: []string{AlertTypeUpsell}}, false},
+		{"severity at the minimum", config.AlertRuleConfig{MinSeverity: "medium"}, true},
+		{"severity below the minimum", config.AlertRuleConfig{MinSeverity: "high"}, false},
+		{"city compared case-insensitively", config.AlertRuleConfig{Cities: []string{"new delhi"}}, true},
+		{"other city", config.AlertRuleConfig{Cities: []string{"Mumbai"}}, false},
+		{"customer type", config.AlertRuleConfig{CustomerTypes: []string{"EXISTING"}}, true},
+		{"other customer type", config.AlertRuleConfig{CustomerTypes: []string{"New"}}, false},
+	}
+	for _, test := range tests {
+		if got := alertRuleMatches(test.rule, alert, row); got != test.want {
+			t.Errorf("%s: alertRuleMatches = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRouteAlertsDeduplicatesPerSeller(t *testing.T) {
+	store := useAlerts(t, config.AlertsConfig{
+		DedupeWindow: time.Hour,
+		Rules: []config.AlertRuleConfig{
+			{Name: "churn-high", AlertTypes: []string{AlertTypeChurnRisk}, MinSeverity: "high", Notifiers: []string{"hook", "mail"}},
+		},
+	})
+	ctx := context.Background()
+	rows := saveInsights(t, store, 7, 7, 8, 9)
+	insights := []Insights{churnInsight("high"), churnInsight("high"), churnInsight("high"), churnInsight("low")}
+
+	if err := routeAlerts(ctx, store, rows, insights); err != nil {
+		t.Fatal(err)
+	}
+	statuses := deliveryStatuses(t, store)
+	want := map[int64]map[string]string{
+		1: {"hook": insightStore.DeliveryPending, "mail": insightStore.DeliveryPending},
+		2: {"hook": insightStore.DeliverySuppressed, "mail": insightStore.DeliverySuppressed}, // same seller in the same batch
+		3: {"hook": insightStore.DeliveryPending, "mail": insightStore.DeliveryPending},
+	}
+	assertStatuses(t, statuses, want)
+
+	// Within the window the seller's alert is suppressed again, other sellers are not affected
+	if err := routeAlerts(ctx, store, saveInsights(t, store, 7, 10), []Insights{churnInsight("high"), churnInsight("high")}); err != nil {
+		t.Fatal(err)
+	}
+	statuses = deliveryStatuses(t, store)
+	want[5] = map[string]string{"hook": insightStore.DeliverySuppressed, "mail": insightStore.DeliverySuppressed}
+	want[6] = map[string]string{"hook": insightStore.DeliveryPending, "mail": insightStore.DeliveryPending}
+	assertStatuses(t, statuses, want)
+}
+
+func TestRouteAlertsWithoutWindowOnlyDeduplicatesTheBatch(t *testing.T) {
+	store := useAlerts(t, config.AlertsConfig{Rules: []config.AlertRuleConfig{{Name: "all", Notifiers: []string{"hook"}}}})
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := routeAlerts(ctx, store, saveInsights(t, store, 7), []Insights{churnInsight("high")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertStatuses(t, deliveryStatuses(t, store), map[int64]map[string]string{
+		1: {"hook": insightStore.DeliveryPending},
+		2: {"hook": insightStore.DeliveryPending},
+	})
+}
+
+func TestAttemptDeliveryBacksOffThenFails(t *testing.T) {
+	useAlerts(t, config.AlertsConfig{MaxAttempts: 4, RetryDelay: time.Second, Timeout: time.Second})
+	notifier.Register(failingNotifier{name: "down"})
+	delivery := insightStore.AlertDelivery{ID: 1, Notifier: "down", Status: insightStore.DeliveryPending}
+
+	for attempt, wantDelay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		before := time.Now()
+		delivery = attemptDelivery(context.Background(), delivery)
+		if delivery.Status != insightStore.DeliveryPending || delivery.Attempts != attempt+1 || delivery.LastError != "stand-in is down" {
+			t.Fatalf("attempt %d: got %+v, want pending with the error", attempt+1, delivery)
+		}
+		if delivery.NextAttemptAt == nil {
+			t.Fatalf("attempt %d: no next attempt scheduled", attempt+1)
+		}
+		if delay := delivery.NextAttemptAt.Sub(before); delay < wantDelay || delay > wantDelay+time.Second/2 {
+			t.Errorf("attempt %d: next attempt in %s, want %s", attempt+1, delay, wantDelay)
+		}
+	}
+
+	delivery = attemptDelivery(context.Background(), delivery)
+	if delivery.Status != insightStore.DeliveryFailed || delivery.Attempts != 4 || delivery.NextAttemptAt != nil {
+		t.Fatalf("after max_attempts got %+v, want failed without a next attempt", delivery)
+	}
+}
+
+func TestDispatchDueAlertsRetriesUntilSent(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	store := useAlerts(t, config.AlertsConfig{
+		MaxAttempts: 3, RetryDelay: time.Millisecond, Timeout: time.Second,
+		Rules: []config.AlertRuleConfig{{Name: "all", Notifiers: []string{"hook"}}},
+	})
+	notifier.Configure(config.AlertsConfig{Notifiers: []config.AlertNotifierConfig{{Name: "hook", Type: notifier.TypeWebhook, URL: server.URL}}})
+	ctx := context.Background()
+	if err := routeAlerts(ctx, store, saveInsights(t, store, 7), []Insights{churnInsight("high")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DispatchDueAlerts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	delivery, err := store.GetAlertDelivery(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != insightStore.DeliveryPending || delivery.Attempts != 1 || delivery.LastError == "" {
+		t.Fatalf("after the failed attempt got %+v", delivery)
+	}
+	if _, retryErr := RetryAlertDelivery(ctx, 1); !errors.Is(retryErr, ErrNotRetryable) {
+		t.Errorf("RetryAlertDelivery of a pending delivery = %v, want ErrNotRetryable", retryErr)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if err := DispatchDueAlerts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	delivery, _ = store.GetAlertDelivery(ctx, 1)
+	if delivery.Status != insightStore.DeliverySent || delivery.Attempts != 2 || delivery.SentAt == nil || delivery.LastError != "" {
+		t.Fatalf("after the second attempt got %+v, want sent", delivery)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("webhook called %d times, want 2", got)
+	}
+}
+
+func TestRetryAlertDeliveryQueuesFailedDelivery(t *testing.T) {
+	store := useAlerts(t, config.AlertsConfig{})
+	ctx := context.Background()
+	row := saveInsights(t, store, 7)[0]
+	ids, err := store.SaveAlertDeliveries(ctx, []insightStore.AlertDelivery{
+		{InsightID: row.ID, Glid: 7, Notifier: "hook", AlertType: AlertTypeChurnRisk, Status: insightStore.DeliveryFailed, Attempts: 5, LastError: "down"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delivery, err := RetryAlertDelivery(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != insightStore.DeliveryPending || delivery.Attempts != 0 || delivery.NextAttemptAt == nil {
+		t.Fatalf("RetryAlertDelivery = %+v, want pending with no attempts", delivery)
+	}
+	if _, err := RetryAlertDelivery(ctx, 99); !errors.Is(err, insightStore.ErrNotFound) {
+		t.Errorf("RetryAlertDelivery(99) = %v, want ErrNotFound", err)
+	}
+}
+
+// saveInsights stores one call-level insight per glid and returns the rows with their IDs
+func saveInsights(t *testing.T, store *insightStore.SQLiteRepository, glids ...int) []insightStore.StoredInsight {
+	t.Helper()
+	rows := make([]insightStore.StoredInsight, len(glids))
+	for i, glid := range glids {
+		rows[i] = insightStore.StoredInsight{Glid: glid, ExecutiveID: "E1", CallIndex: 1, CallDate: "2026-10-17", InsightType: "final"}
+	}
+	ids, err := store.SaveInsights(context.Background(), rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range rows {
+		rows[i].ID = ids[i]
+	}
+	return rows
+}
+
+// deliveryStatuses returns the status of every stored delivery by insight and notifier
+func deliveryStatuses(t *testing.T, store *insightStore.SQLiteRepository) map[int64]map[string]string {
+	t.Helper()
+	deliveries, err := store.ListAlertDeliveries(context.Background(), insightStore.AlertDeliveryFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[int64]map[string]string{}
+	for _, delivery := range deliveries {
+		if statuses[delivery.InsightID] == nil {
+			statuses[delivery.InsightID] = map[string]string{}
+		}
+		statuses[delivery.InsightID][delivery.Notifier] = delivery.Status
+	}
+	return statuses
+}
+
+func assertStatuses(t *testing.T, got, want map[int64]map[string]string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("deliveries for %d insights, want %d: %v", len(got), len(want), got)
+	}
+	for insightID, notifiers := range want {
+		for notifierName, status := range notifiers {
+			if got[insightID][notifierName] != status {
+				t.Errorf("insight %d to %s is %q, want %q", insightID, notifierName, got[insightID][notifierName], status)
+			}
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/insightStore"
+	llm "voice-hack-backend/utilities/llmService"
+	prompts "voice-hack-backend/utilities/promptTemplates"
+)
+
+// ComplianceStep is one knowledge-base step expected on the call and whether the executive did it
+type ComplianceStep struct {
+	Concern        string `json:"concern"` // concern category code
+	Step           string `json:"step"`
+	EscalationTeam string `json:"escalation_team,omitempty"` // set on the escalation step of the category
+	Applicable     bool   `json:"applicable"`                // false when the call gave no reason for the step
+	Followed       bool   `json:"followed"`
+	Evidence       string `json:"evidence,omitempty"` // quote or reason from the transcript
+}
+
+// MissedEscalation is an escalation the call needed that the executive did not make
+type MissedEscalation struct {
+	Concern string `json:"concern"` // concern category code
+	Team    string `json:"team"`
+}
+
+// ComplianceResult is the resolution compliance of one call. The LLM only judges the steps,
+// the score and the missed escalations are computed by the backend.
+type ComplianceResult struct {
+	Score             float64            `json:"score"` // followed / applicable steps in percent, 100 when no step applied
+	StepsFollowed     int                `json:"steps_followed"`
+	StepsApplicable   int                `json:"steps_applicable"`
+	MissedEscalations []MissedEscalation `json:"missed_escalations"`
+	Checklist         []ComplianceStep   `json:"checklist"`
+}
+
+// shouldCheckCompliance reports whether the calls of input are checked against the knowledge base
+func shouldCheckCompliance(input ApiInputParams) bool {
+	if input.CheckCompliance != nil {
+		return *input.CheckCompliance
+	}
+	return config.Get().Compliance.Enabled
+}
+
+// CheckCompliance checks every call-level insight of resp that raised knowledge-base concerns and
+// attaches the result. input must carry the transcripts sent to the LLM. Calls are checked concurrently,
+// as many at once as are transcribed at once. A call whose check fails is left without a result.
+func CheckCompliance(ctx context.Context, resp *ContentGenerationResponse, input ApiInputParams) error {
+	providerName := config.Get().Compliance.Provider
+	if providerName == "" {
+		providerName = input.LLMProvider
+	}
+	provider, err := llm.GetProvider(providerName)
+	if err != nil {
+		return err
+	}
+
+	results := map[string]*ComplianceResult{}
+	slots := make(chan struct{}, callConcurrency(input))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, insight := range resp.Locations {
+		callIndex := CallIndexOf(insight.InsightType, len(input.CallData))
+		if callIndex == 0 || input.TrascriptionURLTxt[callIndex-1] == TranscriptionFailedText {
+			continue
+		}
+		steps := ComplianceSteps(CallConcernCodes(insight))
+		if len(steps) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(insightType string, callIndex int, steps []ComplianceStep) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			result, checkErr := checkCallCompliance(ctx, provider, input, callIndex, steps)
+			if checkErr != nil {
+				fmt.Printf("compliance check of call %d failed: %v\n", callIndex, checkErr)
+				return
+			}
+			mu.Lock()
+			results[insightType] = result
+			mu.Unlock()
+		}(insight.InsightType, callIndex, steps)
+	}
+	wg.Wait()
+
+	for i := range resp.Locations {
+		resp.Locations[i].Compliance = results[resp.Locations[i].InsightType]
+		if resp.Locations[i].Structured != nil {
+			resp.Locations[i].Structured.Compliance = resp.Locations[i].Compliance
+		}
+	}
+	for i := range resp.StructuredInsights {
+		resp.StructuredInsights[i].Compliance = results[resp.StructuredInsights[i].InsightType]
+	}
+	return nil
+}
+
+// CallConcernCodes returns the knowledge-base concern codes raised in a call-level insight, taken
+// from the typed concerns when present and classified from the v1 text by keywords otherwise
+func CallConcernCodes(insight Insights) []string {
+	if insight.Structured != nil {
+		return distinct(insight.Structured.Concerns, func(concern ConcernV2) string { return concern.Category })
+	}
+	var categories []keywordCategory
+	for _, category := range KnowledgeBaseConcerns() {
+		if len(category.Keywords) > 0 {
+			categories = append(categories, keywordCategory{category.Code, category.Keywords})
+		}
+	}
+	return classify(insight.Concerns, categories, "")
+}
+
+// ComplianceSteps lists the steps the knowledge base expects for codes: the resolution, every
+// executive action and the escalation. Codes outside the knowledge base have no steps.
+func ComplianceSteps(codes []string) []ComplianceStep {
+	var steps []ComplianceStep
+	for _, code := range codes {
+		category, err := GetConcernCategory(code)
+		if err != nil {
+			continue
+		}
+		if category.Resolution != "" {
+			steps = append(steps, ComplianceStep{Concern: code, Step: category.Resolution})
+		}
+		for _, action := range category.ExecutiveActions {
+			steps = append(steps, ComplianceStep{Concern: code, Step: action})
+		}
+		if category.EscalationTeam != "" {
+			steps = append(steps, ComplianceStep{Concern: code, Step: "Escalate to " + category.EscalationTeam, EscalationTeam: category.EscalationTeam})
+		}
+	}
+	return steps
+}
+
+// checkCallCompliance asks the provider to judge every step against the transcript of call callIndex
+func checkCallCompliance(ctx context.Context, provider llm.Provider, input ApiInputParams, callIndex int, steps []ComplianceStep) (*ComplianceResult, error) {
+	data := NewCompliancePromptData(input, callIndex, steps)
+	systemPrompt, err := prompts.Render(prompts.Compliance, input.PromptVersion, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var reply struct {
+		Steps []struct {
+			ID         int    `json:"id"`
+			Applicable bool   `json:"applicable"`
+			Followed   bool   `json:"followed"`
+			Evidence   string `json:"evidence"`
+		} `json:"steps"`
+	}
+	_, err = CompleteWithRepair(ctx, provider, llm.CompletionRequest{
+		SystemPrompt: systemPrompt,
+		UserPrompt:   data.Transcript,
+		JSONMode:     true,
+		Schema:       complianceResponseSchema(),
+		SchemaName:   "compliance",
+	}, input, func(content string) []string {
+		reply.Steps = nil
+		if problems := decodeJSON(content, &reply); problems != nil {
+			return problems
+		}
+		var problems []string
+		seen := map[int]bool{}
+		for _, step := range reply.Steps {
+			if step.ID < 1 || step.ID > len(steps) {
+				problems = append(problems, fmt.Sprintf("steps: unknown id %d, ids go from 1 to %d", step.ID, len(steps)))
+			} else if seen[step.ID] {
+				problems = append(problems, fmt.Sprintf("steps: id %d is listed more than once", step.ID))
+			}
+			seen[step.ID] = true
+		}
+		for id := 1; id <= len(steps); id++ {
+			if !seen[id] {
+				problems = append(problems, fmt.Sprintf("steps: id %d is missing", id))
+			}
+		}
+		return problems
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	checklist := append([]ComplianceStep{}, steps...)
+	for _, step := range reply.Steps {
+		checked := &checklist[step.ID-1]
+		checked.Applicable = step.Applicable
+		checked.Followed = step.Applicable && step.Followed
+		checked.Evidence = step.Evidence
+	}
+	return ScoreCompliance(checklist), nil
+}
+
+func complianceResponseSchema() *llm.Schema {
+	return &llm.Schema{
+		Type: "object",
+		Properties: map[string]*llm.Schema{
+			"steps": {Type: "array", Items: &llm.Schema{
+				Type: "object",
+				Properties: map[string]*llm.Schema{
+					"id":         {Type: "integer"},
+					"applicable": {Type: "boolean"},
+					"followed":   {Type: "boolean"},
+					"evidence":   {Type: "string"},
+				},
+				Required: []string{"id", "applicable", "followed", "evidence"},
+			}},
+		},
+		Required: []string{"steps"},
+	}
+}
+
+// ScoreCompliance computes the score and the missed escalations of a judged checklist
+func ScoreCompliance(checklist []ComplianceStep) *ComplianceResult {
+	result := &ComplianceResult{Checklist: checklist, MissedEscalations: []MissedEscalation{}}
+	for _, step := range checklist {
+		if !step.Applicable {
+			continue
+		}
+		result.StepsApplicable++
+		if step.Followed {
+			result.StepsFollowed++
+		} else if step.EscalationTeam != "" {
+			result.MissedEscalations = append(result.MissedEscalations, MissedEscalation{Concern: step.Concern, Team: step.EscalationTeam})
+		}
+	}
+	result.Score = percentOf(result.StepsFollowed, result.StepsApplicable)
+	return result
+}
+
+// percentOf returns part/total in percent with one decimal, 100 when total is 0
+func percentOf(part int, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
+
+// ConcernCompliance is the compliance of one executive on one concern category
+type ConcernCompliance struct {
+	Concern         string  `json:"concern"` // concern category code
+	Label           string  `json:"label"`
+	Calls           int     `json:"calls"`
+	StepsFollowed   int     `json:"steps_followed"`
+	StepsApplicable int     `json:"steps_applicable"`
+	Score           float64 `json:"score"` // followed / applicable steps in percent
+}
+
+// MissedStep counts how often an applicable step was not followed
+type MissedStep struct {
+	Concern string `json:"concern"`
+	Step    string `json:"step"`
+	Count   int    `json:"count"`
+}
+
+// ComplianceSummary aggregates the checked calls of one executive
+type ComplianceSummary struct {
+	ExecutiveID       string              `json:"executive_id"`
+	CallsChecked      int                 `json:"calls_checked"`
+	AverageScore      float64             `json:"average_score"` // mean of the call scores, 0 without checked calls
+	StepsFollowed     int                 `json:"steps_followed"`
+	StepsApplicable   int                 `json:"steps_applicable"`
+	MissedEscalations int                 `json:"missed_escalations"`
+	Concerns          []ConcernCompliance `json:"concerns"`     // by knowledge-base position
+	MissedSteps       []MissedStep        `json:"missed_steps"` // most missed first
+}
+
+// ExecutiveCompliance aggregates the stored compliance results per executive. With an executive id
+// in query the result holds that executive only, with zero counts when none of its calls was checked.
+func ExecutiveCompliance(ctx context.Context, query ExecutiveQuery) ([]ComplianceSummary, error) {
+	stored, err := LoadInsights(ctx, query.Input())
+	if err != nil {
+		return nil, err
+	}
+	summaries := SummarizeCompliance(stored)
+	if query.ExecutiveID != "" && len(summaries) == 0 {
+		summaries = append(summaries, newComplianceSummary(query.ExecutiveID))
+	}
+	return summaries, nil
+}
+
+// SummarizeCompliance groups the checked rows by executive, sorted by executive id
+func SummarizeCompliance(stored []insightStore.StoredInsight) []ComplianceSummary {
+	type accumulator struct {
+		summary    ComplianceSummary
+		scoreTotal float64
+		concerns   map[string]*ConcernCompliance
+		missed     map[[2]string]int
+	}
+	byExecutive := map[string]*accumulator{}
+
+	for _, row := range stored {
+		if row.ComplianceJSON == "" {
+			continue
+		}
+		var result ComplianceResult
+		if err := json.Unmarshal([]byte(row.ComplianceJSON), &result); err != nil {
+			continue
+		}
+		acc := byExecutive[row.ExecutiveID]
+		if acc == nil {
+			acc = &accumulator{
+				summary:  newComplianceSummary(row.ExecutiveID),
+				concerns: map[string]*ConcernCompliance{},
+				missed:   map[[2]string]int{},
+			}
+			byExecutive[row.ExecutiveID] = acc
+		}
+		acc.summary.CallsChecked++
+		acc.scoreTotal += result.Score
+		acc.summary.StepsFollowed += result.StepsFollowed
+		acc.summary.StepsApplicable += result.StepsApplicable
+		acc.summary.MissedEscalations += len(result.MissedEscalations)
+
+		seenConcerns := map[string]bool{}
+		for _, step := range result.Checklist {
+			concern := acc.concerns[step.Concern]
+			if concern == nil {
+				concern = &ConcernCompliance{Concern: step.Concern, Label: ConcernLabel(step.Concern)}
+				acc.concerns[step.Concern] = concern
+			}
+			if !seenConcerns[step.Concern] {
+				seenConcerns[step.Concern] = true
+				concern.Calls++
+			}
+			if !step.Applicable {
+				continue
+			}
+			concern.StepsApplicable++
+			if step.Followed {
+				concern.StepsFollowed++
+			} else {
+				acc.missed[[2]string{step.Concern, step.Step}]++
+			}
+		}
+	}
+
+	position := map[string]int{}
+	for i, category := range ConcernCategories() {
+		position[category.Code] = i
+	}
+	summaries := make([]ComplianceSummary, 0, len(byExecutive))
+	for _, acc := range byExecutive {
+		summary := acc.summary
+		summary.AverageScore = math.Round(acc.scoreTotal*10/float64(summary.CallsChecked)) / 10
+		for _, concern := range acc.concerns {
+			concern.Score = percentOf(concern.StepsFollowed, concern.StepsApplicable)
+			summary.Concerns = append(summary.Concerns, *concern)
+		}
+		sort.Slice(summary.Concerns, func(i, j int) bool {
+			pi, iKnown := position[summary.Concerns[i].Concern]
+			pj, jKnown := position[summary.Concerns[j].Concern]
+			if iKnown != jKnown {
+				return iKnown
+			}
+			if pi != pj {
+				return pi < pj
+			}
+			return summary.Concerns[i].Concern < summary.Concerns[j].Concern
+		})
+		for key, count := range acc.missed {
+			summary.MissedSteps = append(summary.MissedSteps, MissedStep{Concern: key[0], Step: key[1], Count: count})
+		}
+		sort.Slice(summary.MissedSteps, func(i, j int) bool {
+			a, b := summary.MissedSteps[i], summary.MissedSteps[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			if a.Concern != b.Concern {
+				return a.Concern < b.Concern
+			}
+			return a.Step < b.Step
+		})
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].ExecutiveID < summaries[j].ExecutiveID })
+	return summaries
+}
+
+func newComplianceSummary(executiveID string) ComplianceSummary {
+	return ComplianceSummary{ExecutiveID: executiveID, Concerns: []ConcernCompliance{}, MissedSteps: []MissedStep{}}
+}
@@ -0,0 +1,112 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/insightStore"
+	llm "voice-hack-backend/utilities/llmService"
+)
+
+// useComplianceKnowledgeBase loads the concern categories seeded in a fresh store
+func useComplianceKnowledgeBase(t *testing.T) {
+	t.Helper()
+	store, err := insightStore.OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "insights.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	insightStore.SetDefault(store)
+
+	previous := KnowledgeBaseConcerns()
+	if err := LoadConcernCategories(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		knowledgeBaseMu.Lock()
+		knowledgeBase = previous
+		knowledgeBaseMu.Unlock()
+	})
+	if len(KnowledgeBaseConcerns()) == 0 {
+		t.Fatal("the store seeded no concern categories")
+	}
+}
+
+// useComplianceLLM registers a fresh fake provider that answers every check with handler, without repairs
+func useComplianceLLM(t *testing.T, handler func(llm.CompletionRequest) (string, error)) *llm.FakeProvider {
+	t.Helper()
+	cfg := config.Defaults()
+	cfg.LLM.RepairAttempts = 0
+	previous := config.Get()
+	config.Set(&cfg)
+	t.Cleanup(func() { config.Set(previous) })
+
+	fake := llm.NewFakeProvider()
+	fake.Handler = handler
+	llm.RegisterProvider(fake)
+	return fake
+}
+
+func TestCheckComplianceRunsCallsConcurrently(t *testing.T) {
+	useComplianceKnowledgeBase(t)
+	code := KnowledgeBaseConcerns()[0].Code
+	steps := ComplianceSteps([]string{code})
+
+	var inFlight, maxInFlight atomic.Int32
+	fake := useComplianceLLM(t, func(llm.CompletionRequest) (string, error) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		var reply struct {
+			Steps []map[string]any `json:"steps"`
+		}
+		for id := 1; id <= len(steps); id++ {
+			reply.Steps = append(reply.Steps, map[string]any{"id": id, "applicable": true, "followed": true, "evidence": "done"})
+		}
+		content, err := json.Marshal(reply)
+		return string(content), err
+	})
+
+	const calls = 5
+	input := ApiInputParams{LLMProvider: llm.ProviderFake, TranscriptionConcurrency: 2}
+	resp := ContentGenerationResponse{}
+	for i := 1; i <= calls; i++ {
+		input.CallData = append(input.CallData, CallData{Transcript: "Seller: complaint"})
+		input.TrascriptionURLTxt = append(input.TrascriptionURLTxt, "Seller: complaint")
+		resp.Locations = append(resp.Locations, Insights{
+			InsightType: fmt.Sprintf("call_%d", i),
+			Structured:  &InsightsV2{Concerns: []ConcernV2{{Category: code, Description: "complaint"}}},
+		})
+	}
+	resp.Locations = append(resp.Locations, Insights{InsightType: "final"})
+
+	if err := CheckCompliance(context.Background(), &resp, input); err != nil {
+		t.Fatalf("CheckCompliance: %v", err)
+	}
+	if got := len(fake.Requests()); got != calls {
+		t.Errorf("sent %d checks, want one per call", got)
+	}
+	if got := maxInFlight.Load(); got != 2 {
+		t.Errorf("%d checks ran at once, want the transcription concurrency of 2", got)
+	}
+	for _, insight := range resp.Locations {
+		checked := insight.Compliance != nil
+		if checked != (insight.InsightType != "final") {
+			t.Errorf("%s compliance = %+v", insight.InsightType, insight.Compliance)
+		}
+		if checked && insight.Compliance.Score != 100 {
+			t.Errorf("%s score = %v, want 100", insight.InsightType, insight.Compliance.Score)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"voice-hack-backend/utilities/insightStore"
+)
+
+// Leaderboard orderings selectable with ExecutiveQuery.SortBy
+const (
+	SortByComplianceRate     = "compliance_rate"     // highest first (default)
+	SortBySentimentImproved  = "sentiment_improved"  // highest first
+	SortByCallsHandled       = "calls_handled"       // highest first
+	SortByInefficiencyAlerts = "inefficiency_alerts" // fewest first
+	SortByUpsellMissed       = "upsell_missed"       // fewest first
+)
+
+var leaderboardSortKeys = []string{SortByComplianceRate, SortBySentimentImproved, SortByCallsHandled, SortByInefficiencyAlerts, SortByUpsellMissed}
+
+// upsellPitchKeywords show in the resolution or next steps that the executive pitched an upgrade
+var upsellPitchKeywords = []string{"upsell", "upgrad", "higher package", "premium", "trustseal", "trust seal", "leader"}
+
+// ExecutiveQuery holds the filters of the /executives endpoints
+type ExecutiveQuery struct {
+	ExecutiveID string `form:"-"`         // from the path, every executive when empty
+	FromDate    string `form:"from_date"` // first call date, YYYY-MM-DD
+	ToDate      string `form:"to_date"`   // last call date, YYYY-MM-DD
+	LastDays    int    `form:"last_days"` // only calls of the last N days
+
+	SortBy   string `form:"sort_by"`   // leaderboard: one of leaderboardSortKeys, compliance_rate when empty
+	Limit    int    `form:"limit"`     // leaderboard: top N executives, all when 0
+	MinCalls int    `form:"min_calls"` // leaderboard: skip executives with fewer calls
+}
+
+// Input filters the stored insights down to the calls handled by the executive, or by the whole team
+// when none is named, in the queried period
+func (query ExecutiveQuery) Input() ApiInputParams {
+	return ApiInputParams{ExecutiveID: query.ExecutiveID, FromDate: query.FromDate, ToDate: query.ToDate, LastDays: query.LastDays}
+}
+
+// ExecutiveApiResponse is returned by the /executives endpoints
+type ExecutiveApiResponse struct {
+	ApiStatus
+	Scorecard   *ExecutiveScorecard `json:"scorecard,omitempty"`   // /executives/:executive_id/scorecard
+	Leaderboard []LeaderboardEntry  `json:"leaderboard,omitempty"` // /executives/leaderboard
+	Compliance  *ComplianceSummary  `json:"compliance,omitempty"`  // /executives/:executive_id/compliance
+	Executives  []ComplianceSummary `json:"executives,omitempty"`  // /executives/compliance, by executive id
+}
+
+// ExecutiveScorecard aggregates the stored call-level insights of one executive over the queried period
+type ExecutiveScorecard struct {
+	ExecutiveID       string             `json:"executive_id"`
+	CallsHandled      int                `json:"calls_handled"`
+	Sellers           int                `json:"sellers"`                   // distinct GLIDs
+	FirstCallDate     string             `json:"first_call_date,omitempty"` // YYYY-MM-DD
+	LastCallDate      string             `json:"last_call_date,omitempty"`
+	Statistics        *InsightStatistics `json:"statistics,omitempty"` // concern mix, sentiment and alert distributions of the calls
+	SentimentImproved float64            `json:"sentiment_improved"`   // percent of calls ending in a better sentiment than they started
+	SentimentWorsened float64            `json:"sentiment_worsened"`
+
+	InefficiencyAlerts int `json:"inefficiency_alerts"` // calls flagging Executive Inefficiency against the executive
+
+	ComplianceRate    float64 `json:"compliance_rate"` // mean compliance score of the checked calls, 0 without any
+	CallsChecked      int     `json:"calls_checked"`
+	MissedEscalations int     `json:"missed_escalations"`
+
+	UpsellOpportunities int `json:"upsell_opportunities"` // calls flagging an Upsell Opportunity
+	UpsellMissed        int `json:"upsell_missed"`        // of those, calls where the executive did not pitch an upgrade
+}
+
+// LeaderboardEntry is one ranked executive of the team leaderboard
+type LeaderboardEntry struct {
+	Rank int `json:"rank"`
+	ExecutiveScorecard
+}
+
+// GetExecutiveScorecard builds the scorecard of query.ExecutiveID, with zero counts when it handled no stored call
+func GetExecutiveScorecard(ctx context.Context, query ExecutiveQuery) (ExecutiveScorecard, error) {
+	stored, err := LoadInsights(ctx, query.Input())
+	if err != nil {
+		return ExecutiveScorecard{}, err
+	}
+	return BuildScorecard(query.ExecutiveID, stored), nil
+}
+
+// GetLeaderboard ranks every executive with stored calls by query.SortBy
+func GetLeaderboard(ctx context.Context, query ExecutiveQuery) ([]LeaderboardEntry, error) {
+	query.ExecutiveID = ""
+	stored, err := LoadInsights(ctx, query.Input())
+	if err != nil {
+		return nil, err
+	}
+
+	byExecutive := map[string][]insightStore.StoredInsight{}
+	for _, row := range stored {
+		if row.ExecutiveID != "" {
+			byExecutive[row.ExecutiveID] = append(byExecutive[row.ExecutiveID], row)
+		}
+	}
+	entries := make([]LeaderboardEntry, 0, len(byExecutive))
+	for executiveID, rows := range byExecutive {
+		if len(rows) < query.MinCalls {
+			continue
+		}
+		scorecard := BuildScorecard(executiveID, rows)
+		scorecard.Statistics = nil
+		entries = append(entries, LeaderboardEntry{ExecutiveScorecard: scorecard})
+	}
+
+	sortBy := query.SortBy
+	if sortBy == "" {
+		sortBy = SortByComplianceRate
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if better, decided := compareScorecards(sortBy, a.ExecutiveScorecard, b.ExecutiveScorecard); decided {
+			return better
+		}
+		if a.CallsHandled != b.CallsHandled {
+			return a.CallsHandled > b.CallsHandled
+		}
+		return a.ExecutiveID < b.ExecutiveID
+	})
+	if query.Limit > 0 && len(entries) > query.Limit {
+		entries = entries[:query.Limit]
+	}
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return entries, nil
+}
+
+// compareScorecards reports whether a ranks above b on sortBy; decided is false on a tie
+func compareScorecards(sortBy string, a ExecutiveScorecard, b ExecutiveScorecard) (better bool, decided bool) {
+	switch sortBy {
+	case SortByComplianceRate:
+		// Executives without a checked call have no rate and rank last
+		if (a.CallsChecked == 0) != (b.CallsChecked == 0) {
+			return b.CallsChecked == 0, true
+		}
+		return a.ComplianceRate > b.ComplianceRate, a.ComplianceRate != b.ComplianceRate
+	case SortBySentimentImproved:
+		return a.SentimentImproved > b.SentimentImproved, a.SentimentImproved != b.SentimentImproved
+	case SortByCallsHandled:
+		return a.CallsHandled > b.CallsHandled, a.CallsHandled != b.CallsHandled
+	case SortByInefficiencyAlerts:
+		return a.InefficiencyAlerts < b.InefficiencyAlerts, a.InefficiencyAlerts != b.InefficiencyAlerts
+	case SortByUpsellMissed:
+		return a.UpsellMissed < b.UpsellMissed, a.UpsellMissed != b.UpsellMissed
+	}
+	return false, false
+}
+
+// BuildScorecard aggregates the stored rows of one executive
+func BuildScorecard(executiveID string, stored []insightStore.StoredInsight) ExecutiveScorecard {
+	insights := ToInsights(stored)
+	stats := ComputeStatistics(insights)
+	scorecard := ExecutiveScorecard{
+		ExecutiveID:  executiveID,
+		CallsHandled: len(stored),
+		Statistics:   stats,
+	}
+	if len(stored) > 0 {
+		scorecard.SentimentImproved = percentOf(stats.SentimentShift["improved"], len(stored))
+		scorecard.SentimentWorsened = percentOf(stats.SentimentShift["worsened"], len(stored))
+	}
+
+	sellers := map[int]bool{}
+	for i, row := range stored {
+		sellers[row.Glid] = true
+		if row.CallDate != "" && (scorecard.FirstCallDate == "" || row.CallDate < scorecard.FirstCallDate) {
+			scorecard.FirstCallDate = row.CallDate
+		}
+		if row.CallDate > scorecard.LastCallDate {
+			scorecard.LastCallDate = row.CallDate
+		}
+
+		alerts := callAlertLabels(insights[i])
+		if contains(alerts, AlertExecutiveInefficiency) {
+			scorecard.InefficiencyAlerts++
+		}
+		if contains(alerts, AlertUpsellOpportunity) {
+			scorecard.UpsellOpportunities++
+			if !upsellPitched(insights[i]) {
+				scorecard.UpsellMissed++
+			}
+		}
+	}
+	scorecard.Sellers = len(sellers)
+
+	for _, summary := range SummarizeCompliance(stored) {
+		if summary.ExecutiveID == executiveID {
+			scorecard.ComplianceRate = summary.AverageScore
+			scorecard.CallsChecked = summary.CallsChecked
+			scorecard.MissedEscalations = summary.MissedEscalations
+		}
+	}
+	return scorecard
+}
+
+// upsellPitched reports whether the executive pitched an upgrade on a call flagged as an upsell opportunity.
+// An applicable upsell step of the compliance checklist decides; otherwise the resolution and next steps are searched.
+func upsellPitched(insight Insights) bool {
+	if insight.Compliance != nil {
+		for _, step := range insight.Compliance.Checklist {
+			if step.Applicable && strings.Contains(strings.ToLower(step.Step), "upsell") {
+				return step.Followed
+			}
+		}
+	}
+	return containsAny(strings.ToLower(insight.Resolution+" "+insight.NextSteps), upsellPitchKeywords)
+}
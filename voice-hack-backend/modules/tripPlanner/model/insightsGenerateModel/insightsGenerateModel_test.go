@@ -0,0 +1,40 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"voice-hack-backend/utilities/insightStore"
+	llm "voice-hack-backend/utilities/llmService"
+)
+
+// useSummaryStore installs a fresh store holding one insight of glid 7 as the default repository
+func useSummaryStore(t *testing.T) {
+	t.Helper()
+	store, err := insightStore.OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "insights.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	insightStore.SetDefault(store)
+
+	row := insightStore.StoredInsight{Glid: 7, ExecutiveID: "E1", CallIndex: 1, CallDate: "2026-10-17", InsightType: "final"}
+	if _, err := store.SaveInsights(context.Background(), []insightStore.StoredInsight{row}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFinalSummaryWithoutMatchingInsights(t *testing.T) {
+	useSummaryStore(t)
+	fake := llm.NewFakeProvider()
+	llm.RegisterProvider(fake)
+
+	_, err := FinalSummary(context.Background(), ApiInputParams{Glid: 42, LLMProvider: fake.Name()})
+	if !errors.Is(err, ErrNoMatchingInsights) {
+		t.Fatalf("FinalSummary error = %v, want ErrNoMatchingInsights", err)
+	}
+	if len(fake.Requests()) != 0 {
+		t.Error("the LLM was asked to summarise no insights")
+	}
+}
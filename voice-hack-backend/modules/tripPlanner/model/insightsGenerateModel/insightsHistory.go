@@ -0,0 +1,124 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/insightStore"
+)
+
+// PriorCall is one stored insight of the seller shown to the model as history
+type PriorCall struct {
+	InsightID int64
+	CallDate  string
+	Summary   string // one compact prompt line
+}
+
+// shouldIncludeHistory reports whether the seller's stored insights are added to the prompt
+func shouldIncludeHistory(input ApiInputParams) bool {
+	if input.IncludeHistory != nil {
+		return *input.IncludeHistory
+	}
+	return config.Get().History.Enabled
+}
+
+// LoadPriorCalls returns the seller's most recent stored insights up to history.max_calls, newest
+// first, keeping as many as fit in history.token_budget. Calls dated after the request's earliest call
+// and earlier runs of the request's own recordings are left out.
+func LoadPriorCalls(ctx context.Context, input ApiInputParams) ([]PriorCall, error) {
+	cfg := config.Get().History
+	if input.Glid <= 0 || cfg.MaxCalls <= 0 {
+		return nil, nil
+	}
+	repo, err := insightStore.Default()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := insightStore.InsightFilter{Glids: []int{input.Glid}}
+	currentSources := map[string]bool{}
+	for _, callData := range input.CallData {
+		if callDate, ok := insightStore.NormalizeCallDate(callData.CallDate); ok && (filter.ToDate == "" || callDate < filter.ToDate) {
+			filter.ToDate = callDate
+		}
+		if sourceURL := callData.SourceURL(); sourceURL != "" {
+			currentSources[sourceURL] = true
+		}
+	}
+	// Fetch a few more rows than needed so re-submitted calls do not shrink the history
+	filter.Limit = cfg.MaxCalls + len(currentSources)
+	stored, err := repo.ListInsights(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var calls []PriorCall
+	tokens := 0
+	for i, insight := range ToInsights(stored) {
+		row := stored[i]
+		if currentSources[row.SourceURL] {
+			continue
+		}
+		if len(calls) == cfg.MaxCalls {
+			break
+		}
+		summary := priorCallSummary(row, insight)
+		tokens += estimateTokens(summary)
+		if tokens > cfg.TokenBudget {
+			break
+		}
+		calls = append(calls, PriorCall{InsightID: row.ID, CallDate: row.CallDate, Summary: summary})
+	}
+	return calls, nil
+}
+
+// priorCallSummary renders one stored insight as a single history line
+func priorCallSummary(row insightStore.StoredInsight, insight Insights) string {
+	callDate := row.CallDate
+	if callDate == "" {
+		callDate = "unknown date"
+	}
+	parts := []string{fmt.Sprintf("%s, %s call", callDate, valueOr(row.CallType, "unknown type"))}
+	if row.ExecutiveID != "" {
+		parts[0] += " with executive " + row.ExecutiveID
+	}
+	fields := []struct{ name, value string }{
+		{"Concerns", insight.Concerns},
+		{"Resolution", insight.Resolution},
+		{"NextSteps", insight.NextSteps},
+		{"Alert", insight.Alert},
+		{"Sentiment", insight.Sentiment},
+	}
+	for _, field := range fields {
+		if value := strings.Join(strings.Fields(field.value), " "); value != "" {
+			parts = append(parts, field.name+": "+value)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+func valueOr(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// estimateTokens approximates the token count of text at four characters per token
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// PriorCallIDs returns the insight IDs of calls, in the order they were shown
+func PriorCallIDs(calls []PriorCall) []int64 {
+	if len(calls) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(calls))
+	for _, call := range calls {
+		ids = append(ids, call.InsightID)
+	}
+	return ids
+}
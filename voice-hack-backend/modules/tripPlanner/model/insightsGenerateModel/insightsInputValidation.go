@@ -0,0 +1,343 @@
+package insightsGenerateModel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/insightStore"
+	"voice-hack-backend/utilities/language"
+	llm "voice-hack-backend/utilities/llmService"
+	prompts "voice-hack-backend/utilities/promptTemplates"
+)
+
+// FieldError is one invalid request field, returned in ApiResponse.Errors with a 400
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ApiStatus is the outcome embedded in the responses of the seller, executive, action item, alert,
+// knowledge base and job endpoints, filled by the controllers
+type ApiStatus struct {
+	Code   int          `json:"code"`
+	Status string       `json:"status"`
+	Error  string       `json:"error"`
+	Errors []FieldError `json:"errors,omitempty"` // Invalid path, query or body fields, with a 400
+}
+
+// Outcome returns the status of the response embedding it
+func (status *ApiStatus) Outcome() *ApiStatus {
+	return status
+}
+
+// inputRule validates one request field; Check returns why the value is invalid, or "" when it is fine
+type inputRule struct {
+	Field string
+	Check func(input ApiInputParams) string
+}
+
+// callRule validates one field of every call_data entry, an empty Field stands for the entry itself
+type callRule struct {
+	Field string
+	Check func(call CallData) string
+}
+
+// generateRules apply to /insights/generate and /insights/jobs
+var generateRules = []inputRule{
+	{"glid", func(input ApiInputParams) string { return positive(input.Glid) }},
+	{"call_data", func(input ApiInputParams) string { return callCount(len(input.CallData)) }},
+	{"max_call_limit", func(input ApiInputParams) string { return nonNegative(input.MaxCallLimit) }},
+	{"llm_provider", func(input ApiInputParams) string { return knownProvider(input.LLMProvider) }},
+	{"transcription_concurrency", func(input ApiInputParams) string { return nonNegative(input.TranscriptionConcurrency) }},
+	{"transcription_failure_policy", func(input ApiInputParams) string {
+		return oneOf(input.TranscriptionFailurePolicy, FailurePolicyFailFast, FailurePolicySkip, FailurePolicyMark)
+	}},
+	{"schema_version", func(input ApiInputParams) string { return oneOf(input.SchemaVersion, SchemaV1, SchemaV2) }},
+	{"output_language", func(input ApiInputParams) string { return supportedLanguage(input.OutputLanguage) }},
+	{"prompt_version", func(input ApiInputParams) string { return knownPromptVersion(input.PromptVersion) }},
+}
+
+var callRules = []callRule{
+	{"", func(call CallData) string {
+		if count := call.sourceCount(); count == 0 {
+			return "one of call_recording_url, transcript, transcript_url or audio_file is required"
+		} else if count > 1 {
+			return "only one of call_recording_url, transcript, transcript_url or audio_file may be set"
+		}
+		return ""
+	}},
+	{"call_recording_url", func(call CallData) string { return optionalHTTPURL(call.CallRecordingURL) }},
+	{"transcript_url", func(call CallData) string { return optionalHTTPURL(call.TranscriptURL) }},
+	{"audio_file", func(call CallData) string {
+		if call.AudioFile != "" && len(call.Audio) == 0 {
+			return fmt.Sprintf("no file was uploaded in the %q part", call.AudioFile)
+		}
+		return ""
+	}},
+	{"call_date", func(call CallData) string { return optionalDate(call.CallDate) }},
+}
+
+// finalSummaryRules apply to /insights/final
+var finalSummaryRules = []inputRule{
+	{"max_call_limit", func(input ApiInputParams) string { return nonNegative(input.MaxCallLimit) }},
+	{"last_days", func(input ApiInputParams) string { return nonNegative(input.LastDays) }},
+	{"from_date", func(input ApiInputParams) string { return optionalDate(input.FromDate) }},
+	{"to_date", func(input ApiInputParams) string { return optionalDate(input.ToDate) }},
+	{"to_date", func(input ApiInputParams) string { return dateOrder(input.FromDate, input.ToDate) }},
+	{"glids", func(input ApiInputParams) string {
+		for _, glid := range input.Glids {
+			if glid <= 0 {
+				return fmt.Sprintf("every GLID must be positive, got %d", glid)
+			}
+		}
+		return ""
+	}},
+	{"llm_provider", func(input ApiInputParams) string { return knownProvider(input.LLMProvider) }},
+	{"output_language", func(input ApiInputParams) string { return supportedLanguage(input.OutputLanguage) }},
+	{"prompt_version", func(input ApiInputParams) string { return knownPromptVersion(input.PromptVersion) }},
+}
+
+// executiveRules apply to the query of the /executives endpoints
+var executiveRules = []inputRule{
+	{"last_days", func(input ApiInputParams) string { return nonNegative(input.LastDays) }},
+	{"from_date", func(input ApiInputParams) string { return optionalDate(input.FromDate) }},
+	{"to_date", func(input ApiInputParams) string { return optionalDate(input.ToDate) }},
+	{"to_date", func(input ApiInputParams) string { return dateOrder(input.FromDate, input.ToDate) }},
+}
+
+// sellerRules apply to the /sellers endpoints
+var sellerRules = append([]inputRule{
+	{"glid", func(input ApiInputParams) string { return positive(input.Glid) }},
+}, executiveRules...)
+
+// ValidateGenerateInput checks a generate request, including every call_data entry
+func ValidateGenerateInput(input ApiInputParams) []FieldError {
+	fieldErrors := applyRules(input, generateRules)
+	for index, call := range input.CallData {
+		for _, rule := range callRules {
+			if reason := rule.Check(call); reason != "" {
+				field := fmt.Sprintf("call_data[%d]", index)
+				if rule.Field != "" {
+					field += "." + rule.Field
+				}
+				fieldErrors = append(fieldErrors, FieldError{Field: field, Reason: reason})
+			}
+		}
+	}
+	return fieldErrors
+}
+
+// ValidateFinalSummaryInput checks the filters of a /insights/final request
+func ValidateFinalSummaryInput(input ApiInputParams) []FieldError {
+	return applyRules(input, finalSummaryRules)
+}
+
+// ValidateExecutiveQuery checks the query of an /executives request
+func ValidateExecutiveQuery(query ExecutiveQuery) []FieldError {
+	leaderboardChecks := []FieldError{
+		{"sort_by", oneOf(query.SortBy, leaderboardSortKeys...)},
+		{"limit", nonNegative(query.Limit)},
+		{"min_calls", nonNegative(query.MinCalls)},
+	}
+	return append(applyRules(query.Input(), executiveRules), failedChecks(leaderboardChecks)...)
+}
+
+// ValidateSellerQuery checks the path and query of a /sellers request
+func ValidateSellerQuery(query SellerQuery) []FieldError {
+	return applyRules(query.Input(), sellerRules)
+}
+
+// ValidateActionItemQuery checks the query of GET /action-items
+func ValidateActionItemQuery(query ActionItemQuery) []FieldError {
+	return failedChecks([]FieldError{
+		{"glid", nonNegative(query.Glid)},
+		{"owner", oneOf(query.Owner, ownerPersonaValues...)},
+		{"status", oneOf(query.Status, insightStore.ActionItemOpen, insightStore.ActionItemDone, ActionItemOverdue)},
+		{"from_date", optionalDate(query.FromDate)},
+		{"to_date", optionalDate(query.ToDate)},
+		{"to_date", dateOrder(query.FromDate, query.ToDate)},
+		{"limit", nonNegative(query.Limit)},
+	})
+}
+
+// ValidateAlertDeliveryQuery checks the query of GET /alerts/deliveries
+func ValidateAlertDeliveryQuery(query AlertDeliveryQuery) []FieldError {
+	return failedChecks([]FieldError{
+		{"glid", nonNegative(query.Glid)},
+		{"status", oneOf(query.Status, insightStore.DeliveryPending, insightStore.DeliverySent, insightStore.DeliveryFailed,
+			insightStore.DeliverySuppressed)},
+		{"limit", nonNegative(query.Limit)},
+	})
+}
+
+// ValidateActionItemUpdate checks the set fields of a PATCH /action-items/:id body. Overdue cannot be
+// set, it follows from the due date.
+func ValidateActionItemUpdate(update ActionItemUpdate) []FieldError {
+	var checks []FieldError
+	if update.Status != nil {
+		checks = append(checks, FieldError{"status", requiredOneOf(*update.Status, insightStore.ActionItemOpen, insightStore.ActionItemDone)})
+	}
+	if update.DueDate != nil {
+		checks = append(checks, FieldError{"due_date", requiredDate(*update.DueDate)})
+	}
+	if update.Owner != nil {
+		checks = append(checks, FieldError{"owner", requiredOneOf(*update.Owner, ownerPersonaValues...)})
+	}
+	if update.Description != nil && strings.TrimSpace(*update.Description) == "" {
+		checks = append(checks, FieldError{"description", "must not be empty"})
+	}
+	return failedChecks(checks)
+}
+
+// BindFieldErrors turns a JSON binding error into field errors where the field is known
+func BindFieldErrors(err error) []FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{Field: typeErr.Field, Reason: fmt.Sprintf("must be a %s, got %s", jsonType(typeErr.Type.Kind().String()), typeErr.Value)}}
+	}
+	return nil
+}
+
+func applyRules(input ApiInputParams, rules []inputRule) []FieldError {
+	var fieldErrors []FieldError
+	for _, rule := range rules {
+		if reason := rule.Check(input); reason != "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: rule.Field, Reason: reason})
+		}
+	}
+	return fieldErrors
+}
+
+// failedChecks keeps the checks that found a problem
+func failedChecks(checks []FieldError) []FieldError {
+	var fieldErrors []FieldError
+	for _, check := range checks {
+		if check.Reason != "" {
+			fieldErrors = append(fieldErrors, check)
+		}
+	}
+	return fieldErrors
+}
+
+func positive(value int) string {
+	if value <= 0 {
+		return "required and must be positive"
+	}
+	return ""
+}
+
+func nonNegative(value int) string {
+	if value < 0 {
+		return "must not be negative"
+	}
+	return ""
+}
+
+func callCount(count int) string {
+	maxCalls := config.Get().Server.MaxCallsPerRequest
+	switch {
+	case count == 0:
+		return "at least one call is required"
+	case maxCalls > 0 && count > maxCalls:
+		return fmt.Sprintf("at most %d calls are allowed per request, got %d", maxCalls, count)
+	}
+	return ""
+}
+
+func oneOf(value string, allowed ...string) string {
+	if value == "" || contains(allowed, value) {
+		return ""
+	}
+	return fmt.Sprintf("%q must be one of %s", value, strings.Join(allowed, ", "))
+}
+
+func requiredOneOf(value string, allowed ...string) string {
+	if value == "" {
+		return "must not be empty"
+	}
+	return oneOf(value, allowed...)
+}
+
+func knownProvider(name string) string {
+	if name == "" {
+		return ""
+	}
+	if _, err := llm.GetProvider(name); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func supportedLanguage(code string) string {
+	if code == "" || language.IsSupported(code) {
+		return ""
+	}
+	return fmt.Sprintf("%q must be one of %s", code, strings.Join(language.Codes(), ", "))
+}
+
+func knownPromptVersion(version string) string {
+	if version == "" {
+		return ""
+	}
+	registry, err := prompts.Default()
+	if err != nil {
+		return err.Error()
+	}
+	if !registry.Has(version) {
+		return fmt.Sprintf("%q must be one of %s", version, strings.Join(registry.Versions(), ", "))
+	}
+	return ""
+}
+
+func optionalHTTPURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	parsed, err := url.ParseRequestURI(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Sprintf("%q is not an http(s) URL", raw)
+	}
+	return ""
+}
+
+func optionalDate(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	if _, ok := insightStore.NormalizeCallDate(raw); !ok {
+		return fmt.Sprintf("%q is not a date, use YYYY-MM-DD", raw)
+	}
+	return ""
+}
+
+func requiredDate(raw string) string {
+	if raw == "" {
+		return "must not be empty"
+	}
+	return optionalDate(raw)
+}
+
+func dateOrder(fromRaw string, toRaw string) string {
+	fromDate, fromOk := insightStore.NormalizeCallDate(fromRaw)
+	toDate, toOk := insightStore.NormalizeCallDate(toRaw)
+	if fromRaw != "" && toRaw != "" && fromOk && toOk && fromDate > toDate {
+		return fmt.Sprintf("must not be before from_date %s", fromDate)
+	}
+	return ""
+}
+
+// jsonType names a Go kind the way API clients know it
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "slice" || kind == "array":
+		return "list"
+	case kind == "struct" || kind == "map":
+		return "object"
+	}
+	return kind
+}
@@ -0,0 +1,170 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"voice-hack-backend/utilities/insightStore"
+)
+
+// ConcernCodeOther is the category of concerns outside the knowledge base, always valid
+const ConcernCodeOther = "other"
+
+// ConcernCategoryApiResponse is returned by the /knowledge-base/concerns endpoints
+type ConcernCategoryApiResponse struct {
+	ApiStatus
+	Category   *insightStore.ConcernCategory  `json:"category,omitempty"`
+	Categories []insightStore.ConcernCategory `json:"categories,omitempty"`
+}
+
+var (
+	knowledgeBaseMu sync.RWMutex
+	knowledgeBase   []insightStore.ConcernCategory // loaded by LoadConcernCategories, refreshed after every change
+)
+
+var concernCode = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// LoadConcernCategories reads the knowledge base from the insight store into memory
+func LoadConcernCategories(ctx context.Context) error {
+	repo, err := insightStore.Default()
+	if err != nil {
+		return err
+	}
+	categories, err := repo.ListConcernCategories(ctx)
+	if err != nil {
+		return err
+	}
+	knowledgeBaseMu.Lock()
+	defer knowledgeBaseMu.Unlock()
+	knowledgeBase = categories
+	return nil
+}
+
+// KnowledgeBaseConcerns returns the knowledge base categories in prompt order
+func KnowledgeBaseConcerns() []insightStore.ConcernCategory {
+	knowledgeBaseMu.RLock()
+	defer knowledgeBaseMu.RUnlock()
+	return append([]insightStore.ConcernCategory{}, knowledgeBase...)
+}
+
+// ConcernCategories returns the knowledge base categories followed by other
+func ConcernCategories() []insightStore.ConcernCategory {
+	return append(KnowledgeBaseConcerns(), insightStore.ConcernCategory{Code: ConcernCodeOther, Label: ConcernOther})
+}
+
+// GetConcernCategory returns the knowledge base category with code
+func GetConcernCategory(code string) (insightStore.ConcernCategory, error) {
+	for _, category := range KnowledgeBaseConcerns() {
+		if category.Code == code {
+			return category, nil
+		}
+	}
+	return insightStore.ConcernCategory{}, fmt.Errorf("concern category %s %w", code, insightStore.ErrNotFound)
+}
+
+// ValidateConcernCategory checks a category sent to the create and update endpoints
+func ValidateConcernCategory(category insightStore.ConcernCategory) []FieldError {
+	var fieldErrors []FieldError
+	add := func(field string, reason string) {
+		fieldErrors = append(fieldErrors, FieldError{Field: field, Reason: reason})
+	}
+	switch {
+	case !concernCode.MatchString(category.Code):
+		add("code", fmt.Sprintf("%q must be snake_case, e.g. lead_refund", category.Code))
+	case category.Code == ConcernCodeOther:
+		add("code", "other is reserved for concerns outside the knowledge base")
+	}
+	if strings.TrimSpace(category.Label) == "" {
+		add("label", "is required")
+	}
+	if strings.TrimSpace(category.Resolution) == "" {
+		add("resolution", "is required")
+	}
+	if category.Position < 0 {
+		add("position", "must not be negative")
+	}
+	return fieldErrors
+}
+
+// CreateConcernCategory adds a category to the knowledge base; without a position it goes last
+func CreateConcernCategory(ctx context.Context, category insightStore.ConcernCategory) (insightStore.ConcernCategory, error) {
+	repo, err := insightStore.Default()
+	if err != nil {
+		return category, err
+	}
+	category = normalizeConcernCategory(category)
+	if category.Position == 0 {
+		for _, existing := range KnowledgeBaseConcerns() {
+			category.Position = max(category.Position, existing.Position)
+		}
+		category.Position++
+	}
+	if err := repo.CreateConcernCategory(ctx, category); err != nil {
+		return category, err
+	}
+	if err := LoadConcernCategories(ctx); err != nil {
+		return category, err
+	}
+	return GetConcernCategory(category.Code)
+}
+
+// UpdateConcernCategory replaces the category with code; the code itself cannot change
+func UpdateConcernCategory(ctx context.Context, code string, category insightStore.ConcernCategory) (insightStore.ConcernCategory, error) {
+	repo, err := insightStore.Default()
+	if err != nil {
+		return category, err
+	}
+	category.Code = code
+	category = normalizeConcernCategory(category)
+	if err := repo.UpdateConcernCategory(ctx, category); err != nil {
+		return category, err
+	}
+	if err := LoadConcernCategories(ctx); err != nil {
+		return category, err
+	}
+	return GetConcernCategory(code)
+}
+
+// DeleteConcernCategory removes the category with code. Stored insights keep the code, which then
+// shows as is instead of its label.
+func DeleteConcernCategory(ctx context.Context, code string) error {
+	repo, err := insightStore.Default()
+	if err != nil {
+		return err
+	}
+	if err := repo.DeleteConcernCategory(ctx, code); err != nil {
+		return err
+	}
+	return LoadConcernCategories(ctx)
+}
+
+// normalizeConcernCategory trims the text fields, drops empty list entries and lowercases the keywords
+func normalizeConcernCategory(category insightStore.ConcernCategory) insightStore.ConcernCategory {
+	category.Label = strings.TrimSpace(category.Label)
+	category.Description = strings.TrimSpace(category.Description)
+	category.Resolution = strings.TrimSpace(category.Resolution)
+	category.EscalationTeam = strings.TrimSpace(category.EscalationTeam)
+	category.ExecutiveActions = trimList(category.ExecutiveActions)
+	category.SellerActions = trimList(category.SellerActions)
+	// Keywords keep their spaces, "app " must not match "application"
+	keywords := make([]string, 0, len(category.Keywords))
+	for _, keyword := range category.Keywords {
+		if strings.TrimSpace(keyword) != "" {
+			keywords = append(keywords, strings.ToLower(keyword))
+		}
+	}
+	category.Keywords = keywords
+	return category
+}
+
+func trimList(values []string) []string {
+	trimmed := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
@@ -0,0 +1,171 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/language"
+	llm "voice-hack-backend/utilities/llmService"
+	"voice-hack-backend/utilities/transcript"
+)
+
+// DetectLanguages detects the language of every transcript
+func DetectLanguages(texts []string) []language.Detection {
+	detections := make([]language.Detection, 0, len(texts))
+	for _, text := range texts {
+		if text == TranscriptionFailedText {
+			detections = append(detections, language.Detect(""))
+			continue
+		}
+		detections = append(detections, language.Detect(text))
+	}
+	return detections
+}
+
+// OutputLanguage is the language code the insight text is written in
+func OutputLanguage(input ApiInputParams) string {
+	if input.OutputLanguage != "" {
+		return input.OutputLanguage
+	}
+	return config.Get().Language.OutputLanguage
+}
+
+// shouldTranslate reports whether the transcripts of input are translated before generation
+func shouldTranslate(input ApiInputParams) bool {
+	if input.Translate != nil {
+		return *input.Translate
+	}
+	return config.Get().Language.Translate
+}
+
+// TranslateTranscripts translates every transcript that is not already in language.translate_to, using
+// the configured translation provider. Speaker turns are translated line by line so they keep their
+// speakers and timestamps. A call whose translation fails keeps its original text.
+func TranslateTranscripts(ctx context.Context, input ApiInputParams) (ApiInputParams, error) {
+	cfg := config.Get().Language
+	providerName := cfg.TranslationProvider
+	if providerName == "" {
+		providerName = input.LLMProvider
+	}
+	provider, err := llm.GetProvider(providerName)
+	if err != nil {
+		return input, err
+	}
+
+	texts := append([]string{}, input.TrascriptionURLTxt...)
+	transcripts := append([]transcript.Transcript{}, input.Transcripts...)
+	translated := make([]bool, len(texts))
+	for i := range texts {
+		if i >= len(input.Languages) || !needsTranslation(input.Languages[i].Code, cfg.TranslateTo) {
+			continue
+		}
+
+		diarized := i < len(transcripts) && transcripts[i].Diarized()
+		lines := []string{texts[i]}
+		if diarized {
+			lines = make([]string, len(transcripts[i].Utterances))
+			for j, utterance := range transcripts[i].Utterances {
+				lines[j] = utterance.Text
+			}
+		}
+		translatedLines, translateErr := translateLines(ctx, provider, lines, input.Languages[i], cfg.TranslateTo, input)
+		if translateErr != nil {
+			fmt.Printf("translation of call %d failed, keeping the original text: %v\n", i+1, translateErr)
+			continue
+		}
+
+		if diarized {
+			utterances := make([]transcript.Utterance, len(transcripts[i].Utterances))
+			for j, utterance := range transcripts[i].Utterances {
+				utterance.Text = translatedLines[j]
+				utterances[j] = utterance
+			}
+			transcripts[i].Utterances = utterances
+			texts[i] = transcripts[i].Text()
+		} else {
+			texts[i] = translatedLines[0]
+		}
+		translated[i] = true
+	}
+
+	input.TrascriptionURLTxt = texts
+	input.Transcripts = transcripts
+	input.Translated = translated
+	return input, nil
+}
+
+func needsTranslation(code string, target string) bool {
+	return code != language.Unknown && !strings.EqualFold(code, target)
+}
+
+// translateLines asks the provider for a line-by-line translation, re-prompting when the line count differs
+func translateLines(ctx context.Context, provider llm.Provider, lines []string, source language.Detection, target string, input ApiInputParams) ([]string, error) {
+	var reply struct {
+		Lines []string `json:"lines"`
+	}
+	userPrompt, _ := json.Marshal(map[string]any{"lines": lines})
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("You translate IndiaMART customer call transcripts from %s into %s.\n", source.Name, language.Name(target)))
+	b.WriteString("- Translate every entry of the input \"lines\" array faithfully and keep its position; do not merge, split, summarise or drop lines.\n")
+	b.WriteString("- Keep product names, brand names, numbers, amounts and placeholders in square brackets such as [PHONE_1] unchanged.\n")
+	b.WriteString("- Lines already in the target language are returned as they are.\n")
+	b.WriteString(fmt.Sprintf("- Return JSON {\"lines\": [...]} with exactly %d lines.\n", len(lines)))
+
+	_, err := CompleteWithRepair(ctx, provider, llm.CompletionRequest{
+		SystemPrompt: b.String(),
+		UserPrompt:   string(userPrompt),
+		JSONMode:     true,
+		Schema: &llm.Schema{
+			Type:       "object",
+			Properties: map[string]*llm.Schema{"lines": {Type: "array", Items: &llm.Schema{Type: "string"}}},
+			Required:   []string{"lines"},
+		},
+		SchemaName: "translation",
+	}, input, func(content string) []string {
+		reply.Lines = nil
+		if problems := decodeJSON(content, &reply); problems != nil {
+			return problems
+		}
+		if len(reply.Lines) != len(lines) {
+			return []string{fmt.Sprintf("lines: expected exactly %d lines, got %d", len(lines), len(reply.Lines))}
+		}
+		return nil
+	})
+	return reply.Lines, err
+}
+
+// callLanguagePromptText describes the language of call i for the user prompt
+func callLanguagePromptText(input ApiInputParams, i int) string {
+	if i >= len(input.Languages) || input.Languages[i].Code == language.Unknown {
+		return ""
+	}
+	detected := input.Languages[i]
+	text := fmt.Sprintf("- Language: %s (%s)", detected.Name, detected.Code)
+	if i < len(input.Translated) && input.Translated[i] {
+		text += fmt.Sprintf(", transcript below translated to %s", language.Name(config.Get().Language.TranslateTo))
+	}
+	return text + "\n"
+}
+
+// AttachCallLanguages records the detected language on every call-level insight
+func AttachCallLanguages(resp *ContentGenerationResponse, input ApiInputParams) {
+	languageOf := func(insightType string) string {
+		callIndex := CallIndexOf(insightType, len(input.CallData))
+		if callIndex == 0 || callIndex > len(input.Languages) {
+			return ""
+		}
+		return input.Languages[callIndex-1].Code
+	}
+	for i := range resp.Locations {
+		resp.Locations[i].Language = languageOf(resp.Locations[i].InsightType)
+		if resp.Locations[i].Structured != nil {
+			resp.Locations[i].Structured.Language = resp.Locations[i].Language
+		}
+	}
+	for i := range resp.StructuredInsights {
+		resp.StructuredInsights[i].Language = languageOf(resp.StructuredInsights[i].InsightType)
+	}
+}
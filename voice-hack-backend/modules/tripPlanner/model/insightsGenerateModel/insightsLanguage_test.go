@@ -0,0 +1,110 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/language"
+	llm "voice-hack-backend/utilities/llmService"
+)
+
+// useTranslationLLM registers a fresh fake provider that translates with handler
+func useTranslationLLM(t *testing.T, repairAttempts int, handler func(llm.CompletionRequest) (string, error)) *llm.FakeProvider {
+	t.Helper()
+	cfg := config.Defaults()
+	cfg.LLM.RepairAttempts = repairAttempts
+	previous := config.Get()
+	config.Set(&cfg)
+	t.Cleanup(func() { config.Set(previous) })
+
+	fake := llm.NewFakeProvider()
+	fake.Handler = handler
+	llm.RegisterProvider(fake)
+	return fake
+}
+
+// prefixTranslator answers a translation request with every line prefixed by "EN: "
+func prefixTranslator(req llm.CompletionRequest) (string, error) {
+	var request struct {
+		Lines []string `json:"lines"`
+	}
+	if err := json.Unmarshal([]byte(req.UserPrompt), &request); err != nil {
+		return "", err
+	}
+	for i, line := range request.Lines {
+		request.Lines[i] = "EN: " + line
+	}
+	reply, err := json.Marshal(request)
+	return string(reply), err
+}
+
+// transcribedInput is a request whose transcripts went through applyTranscriptionPolicy
+func transcribedInput(texts ...string) ApiInputParams {
+	input := ApiInputParams{LLMProvider: llm.ProviderFake}
+	results := make([]CallTranscript, len(texts))
+	for i, text := range texts {
+		input.CallData = append(input.CallData, CallData{Transcript: text})
+		results[i] = CallTranscript{Text: text}
+	}
+	return applyTranscriptionPolicy(input, results)
+}
+
+func TestDetectLanguagesOfTranscripts(t *testing.T) {
+	detections := DetectLanguages([]string{
+		"Executive: aap ka order kya hai\nSeller: abhi kuch nahi chahiye bhai",
+		"Executive: how are the leads this month\nSeller: they are fine, thank you",
+		"विक्रेता: मुझे लीड नहीं मिल रही है",
+		TranscriptionFailedText,
+	})
+	for i, want := range []string{language.Hinglish, language.English, language.Hindi, language.Unknown} {
+		if detections[i].Code != want {
+			t.Errorf("call %d detected as %q, want %q", i+1, detections[i].Code, want)
+		}
+	}
+}
+
+func TestTranslateTranscriptsKeepsSpeakerTurns(t *testing.T) {
+	fake := useTranslationLLM(t, 0, prefixTranslator)
+	input := transcribedInput(
+		"Executive: aap ka order kya hai\nSeller: abhi kuch nahi chahiye bhai",
+		"Executive: how are the leads this month\nSeller: they are fine, thank you",
+	)
+
+	translated, err := TranslateTranscripts(context.Background(), input)
+	if err != nil {
+		t.Fatalf("TranslateTranscripts: %v", err)
+	}
+	if len(fake.Requests()) != 1 {
+		t.Fatalf("sent %d translation requests, want one for the Hinglish call", len(fake.Requests()))
+	}
+	if !translated.Translated[0] || translated.Translated[1] {
+		t.Errorf("Translated = %v, want only the first call", translated.Translated)
+	}
+	utterances := translated.Transcripts[0].Utterances
+	if len(utterances) != 2 || utterances[0].Text != "EN: aap ka order kya hai" || utterances[1].Role != input.Transcripts[0].Utterances[1].Role {
+		t.Errorf("translated turns = %+v, want every turn translated with its speaker", utterances)
+	}
+	if translated.TrascriptionURLTxt[0] != "EN: aap ka order kya hai\nEN: abhi kuch nahi chahiye bhai" {
+		t.Errorf("translated text = %q", translated.TrascriptionURLTxt[0])
+	}
+	if translated.TrascriptionURLTxt[1] != input.TrascriptionURLTxt[1] {
+		t.Errorf("English call changed to %q", translated.TrascriptionURLTxt[1])
+	}
+}
+
+func TestTranslateTranscriptsKeepsOriginalOnFailure(t *testing.T) {
+	fake := useTranslationLLM(t, 1, func(llm.CompletionRequest) (string, error) { return `{"lines": ["only one line"]}`, nil })
+	input := transcribedInput("Executive: aap ka order kya hai\nSeller: abhi kuch nahi chahiye bhai")
+
+	translated, err := TranslateTranscripts(context.Background(), input)
+	if err != nil {
+		t.Fatalf("TranslateTranscripts: %v", err)
+	}
+	if len(fake.Requests()) != 2 {
+		t.Errorf("sent %d requests, want the first attempt and one repair", len(fake.Requests()))
+	}
+	if translated.Translated[0] || translated.TrascriptionURLTxt[0] != input.TrascriptionURLTxt[0] {
+		t.Errorf("call = %q (translated %v), want the original text", translated.TrascriptionURLTxt[0], translated.Translated[0])
+	}
+}
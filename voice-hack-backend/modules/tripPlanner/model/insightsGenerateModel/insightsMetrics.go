@@ -0,0 +1,116 @@
+package insightsGenerateModel
+
+import (
+	"fmt"
+	"strings"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/transcript"
+)
+
+// ComputeCallMetrics measures every parsed transcript; entries are nil for calls without timestamps or speakers
+func ComputeCallMetrics(transcripts []transcript.Transcript) []*transcript.Metrics {
+	holdGap := config.Get().Metrics.HoldGap.Seconds()
+	metrics := make([]*transcript.Metrics, len(transcripts))
+	for i, parsed := range transcripts {
+		if measured, ok := transcript.ComputeMetrics(parsed, holdGap); ok {
+			metrics[i] = &measured
+		}
+	}
+	return metrics
+}
+
+// MetricAlerts raises executive inefficiency alerts from measured metrics, independently of the LLM
+func MetricAlerts(metrics transcript.Metrics, cfg config.MetricsConfig) []AlertV2 {
+	var alerts []AlertV2
+	raise := func(severity string, format string, args ...any) {
+		alerts = append(alerts, AlertV2{
+			Type:        AlertTypeExecutiveInefficiency,
+			Severity:    severity,
+			Description: "Measured: " + fmt.Sprintf(format, args...),
+		})
+	}
+
+	if holdAlert := cfg.HoldAlert.Seconds(); metrics.HoldSeconds > holdAlert {
+		severity := "medium"
+		if metrics.HoldSeconds > 2*holdAlert {
+			severity = "high"
+		}
+		raise(severity, "hold time %.0fs over %d hold(s), above %.0fs", metrics.HoldSeconds, metrics.HoldCount, holdAlert)
+	}
+	if silenceAlert := cfg.SilenceAlert.Seconds(); metrics.LongestSilenceSeconds > silenceAlert {
+		raise("medium", "longest silence %.0fs, above %.0fs", metrics.LongestSilenceSeconds, silenceAlert)
+	}
+	if metrics.ExecutiveTalkShare > cfg.MaxExecutiveTalkShare {
+		raise("low", "executive talked %.0f%% of the time, above %.0f%%", metrics.ExecutiveTalkShare*100, cfg.MaxExecutiveTalkShare*100)
+	}
+	if metrics.ExecutiveInterruptions > cfg.MaxInterruptions {
+		raise("medium", "executive interrupted the seller %d times, above %d", metrics.ExecutiveInterruptions, cfg.MaxInterruptions)
+	}
+	return alerts
+}
+
+// metricsPromptText summarises one call's metrics and alerts for the user prompt
+func metricsPromptText(metrics transcript.Metrics, alerts []AlertV2) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("- Measured Metrics: duration %.0fs, executive talk %.0f%%, seller talk %.0f%%, longest silence %.0fs, hold time %.0fs, interruptions %d (by executive %d), executive %.0f wpm, seller %.0f wpm\n",
+		metrics.DurationSeconds, metrics.ExecutiveTalkShare*100, metrics.SellerTalkShare*100, metrics.LongestSilenceSeconds,
+		metrics.HoldSeconds, metrics.Interruptions, metrics.ExecutiveInterruptions, metrics.ExecutiveWPM, metrics.SellerWPM))
+	for _, alert := range alerts {
+		b.WriteString(fmt.Sprintf("- Backend Alert (%s): %s\n", alert.Severity, alert.Description))
+	}
+	return b.String()
+}
+
+// AttachCallMetrics adds the measured metrics and their alerts to every call-level insight,
+// in the flattened v1 blocks as well as the typed v2 ones
+func AttachCallMetrics(resp *ContentGenerationResponse, input ApiInputParams) {
+	cfg := config.Get().Metrics
+	callCount := len(input.CallData)
+	metricsOf := func(insightType string) (*transcript.Metrics, []AlertV2) {
+		callIndex := CallIndexOf(insightType, callCount)
+		if callIndex == 0 || callIndex > len(input.CallMetrics) || input.CallMetrics[callIndex-1] == nil {
+			return nil, nil
+		}
+		metrics := input.CallMetrics[callIndex-1]
+		return metrics, MetricAlerts(*metrics, cfg)
+	}
+
+	for i := range resp.Locations {
+		insight := &resp.Locations[i]
+		metrics, alerts := metricsOf(insight.InsightType)
+		if metrics == nil {
+			continue
+		}
+		insight.Metrics = metrics
+		insight.Alert = appendAlertText(insight.Alert, alerts)
+		if insight.Structured != nil {
+			insight.Structured.Metrics = metrics
+			// Structured shares its Alerts array with StructuredInsights, append to a copy
+			insight.Structured.Alerts = append(append([]AlertV2{}, insight.Structured.Alerts...), alerts...)
+		}
+	}
+	for i := range resp.StructuredInsights {
+		insight := &resp.StructuredInsights[i]
+		metrics, alerts := metricsOf(insight.InsightType)
+		if metrics == nil {
+			continue
+		}
+		insight.Metrics = metrics
+		insight.Alerts = append(insight.Alerts, alerts...)
+	}
+}
+
+// appendAlertText adds alerts to a free-text v1 Alert, replacing "None"
+func appendAlertText(alert string, alerts []AlertV2) string {
+	if len(alerts) == 0 {
+		return alert
+	}
+	texts := make([]string, 0, len(alerts)+1)
+	if trimmed := strings.TrimSpace(alert); trimmed != "" && !strings.EqualFold(trimmed, AlertNone) {
+		texts = append(texts, trimmed)
+	}
+	for _, measured := range alerts {
+		texts = append(texts, fmt.Sprintf("%s (%s): %s", alertTypeLabels[measured.Type], measured.Severity, measured.Description))
+	}
+	return strings.Join(texts, "; ")
+}
@@ -0,0 +1,43 @@
+package insightsGenerateModel
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/transcript"
+)
+
+func TestMetricAlerts(t *testing.T) {
+	cfg := config.MetricsConfig{HoldAlert: time.Minute, SilenceAlert: 30 * time.Second, MaxExecutiveTalkShare: 0.7, MaxInterruptions: 2}
+	for _, tc := range []struct {
+		name    string
+		metrics transcript.Metrics
+		want    []string // severity and the start of the description of every alert
+	}{
+		{"within every threshold", transcript.Metrics{HoldSeconds: 60, LongestSilenceSeconds: 30, ExecutiveTalkShare: 0.7, ExecutiveInterruptions: 2}, nil},
+		{"hold just above the alert", transcript.Metrics{HoldSeconds: 61, HoldCount: 2}, []string{"medium Measured: hold time 61s over 2 hold(s)"}},
+		{"hold at twice the alert", transcript.Metrics{HoldSeconds: 120, HoldCount: 1}, []string{"medium Measured: hold time 120s"}},
+		{"hold above twice the alert", transcript.Metrics{HoldSeconds: 121, HoldCount: 3}, []string{"high Measured: hold time 121s"}},
+		{"long silence", transcript.Metrics{LongestSilenceSeconds: 31}, []string{"medium Measured: longest silence 31s"}},
+		{"executive talks too much", transcript.Metrics{ExecutiveTalkShare: 0.75}, []string{"low Measured: executive talked 75%"}},
+		{"executive interrupts", transcript.Metrics{Interruptions: 5, ExecutiveInterruptions: 3}, []string{"medium Measured: executive interrupted the seller 3 times"}},
+		{
+			"every alert in order",
+			transcript.Metrics{HoldSeconds: 200, LongestSilenceSeconds: 90, ExecutiveTalkShare: 0.9, ExecutiveInterruptions: 4},
+			[]string{"high Measured: hold time", "medium Measured: longest silence", "low Measured: executive talked", "medium Measured: executive interrupted"},
+		},
+	} {
+		alerts := MetricAlerts(tc.metrics, cfg)
+		if len(alerts) != len(tc.want) {
+			t.Errorf("%s: alerts = %+v, want %d", tc.name, alerts, len(tc.want))
+			continue
+		}
+		for i, alert := range alerts {
+			got := alert.Severity + " " + alert.Description
+			if alert.Type != AlertTypeExecutiveInefficiency || !strings.HasPrefix(got, tc.want[i]) {
+				t.Errorf("%s: alert %d = %s %q, want %q", tc.name, i+1, alert.Type, got, tc.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/globalFunctions"
+	"voice-hack-backend/utilities/language"
+	prompts "voice-hack-backend/utilities/promptTemplates"
+	"voice-hack-backend/utilities/redaction"
+	"voice-hack-backend/utilities/transcript"
+	transcription "voice-hack-backend/utilities/transcriptionService"
+	urlMedia "voice-hack-backend/utilities/urlMedia"
+)
+
+// Pipeline stages reported through ProgressFunc
+const (
+	StageTranscribing = "transcribing"
+	StageRetrieving   = "retrieving_samples"
+	StageGenerating   = "generating"
+	StageCompliance   = "checking_compliance"
+	StageStoring      = "storing"
+)
+
+// Transcription failure policies, chosen per request
+const (
+	FailurePolicyFailFast = "fail_fast" // abort the request on the first failed call (default)
+	FailurePolicySkip     = "skip"      // drop failed calls and continue with the rest
+	FailurePolicyMark     = "mark"      // keep failed calls with TranscriptionFailedText as transcript
+)
+
+const TranscriptionFailedText = "[transcription failed]"
+
+// ProgressFunc is called whenever the pipeline moves forward; done/total are only set while transcribing
+type ProgressFunc func(stage string, done int, total int)
+
+// RunInsightsPipeline transcribes every call, retrieves sample calls, generates and stores the insights.
+// The returned input carries the transcripts so callers can log them. Insights that could not be stored
+// are still returned, with the failure in StoreError.
+func RunInsightsPipeline(ctx context.Context, input ApiInputParams, progress ProgressFunc) (ApiInputParams, ContentGenerationResponse, error) {
+	if progress == nil {
+		progress = func(string, int, int) {}
+	}
+	if input.SchemaVersion != "" && input.SchemaVersion != SchemaV1 && input.SchemaVersion != SchemaV2 {
+		return input, ContentGenerationResponse{}, fmt.Errorf("unknown schema_version %q", input.SchemaVersion)
+	}
+	// Pin the prompt version so every prompt of the request and the stored insights agree
+	input.PromptVersion = prompts.Resolve(input.PromptVersion)
+
+	// Call URL Media to get text from call recording URLs, several calls at a time
+	transcripts, transcribeErr := TranscribeCalls(ctx, input, progress)
+	if transcribeErr != nil {
+		return input, ContentGenerationResponse{}, transcribeErr
+	}
+	input = applyTranscriptionPolicy(input, transcripts)
+	if len(input.CallData) == 0 {
+		return input, ContentGenerationResponse{}, errors.New("transcription failed for every call")
+	}
+
+	progress(StageRetrieving, 0, 0)
+	input.SampleCalls = FetchSampleCalls(input)
+	if shouldIncludeHistory(input) {
+		history, historyErr := LoadPriorCalls(ctx, input)
+		if historyErr != nil {
+			fmt.Println("seller history skipped:", historyErr)
+		}
+		input.History = history
+	}
+
+	progress(StageGenerating, 0, 0)
+	promptInput, redactor := RedactForLLM(input)
+	if shouldTranslate(promptInput) {
+		translatedInput, translateErr := TranslateTranscripts(ctx, promptInput)
+		if translateErr != nil {
+			fmt.Println("transcript translation skipped:", translateErr)
+		} else {
+			promptInput = translatedInput
+		}
+	}
+	userQuery, queryErr := GenerateUserQuery(promptInput)
+	if queryErr != nil {
+		return input, ContentGenerationResponse{}, queryErr
+	}
+	resp, respErr := GenerateVersionedInsights(ctx, userQuery, promptInput)
+	if respErr != nil {
+		return input, ContentGenerationResponse{}, respErr
+	}
+	if redactor != nil && redactor.Count() > 0 && redaction.KeepMapping() {
+		redactionID, saveErr := redaction.SaveMapping(redactor.Mapping())
+		if saveErr != nil {
+			fmt.Println("failed to save redaction mapping:", saveErr)
+		}
+		resp.RedactionID = redactionID
+	}
+	resp.PriorCallsUsed = PriorCallIDs(input.History)
+
+	AttachCallMetrics(&resp, input)
+	AttachCallLanguages(&resp, input)
+
+	if shouldCheckCompliance(promptInput) {
+		progress(StageCompliance, 0, 0)
+		if complianceErr := CheckCompliance(ctx, &resp, promptInput); complianceErr != nil {
+			fmt.Println("compliance check skipped:", complianceErr)
+		}
+	}
+
+	// Multi-call requests get exact statistics over the call-level blocks
+	if len(input.CallData) > 1 {
+		resp.Statistics = ComputeStatistics(CallLevelInsights(resp.Locations, len(input.CallData)))
+	}
+
+	progress(StageStoring, 0, 0)
+	if _, storeErr := StoreInsights(ctx, input, resp); storeErr != nil {
+		fmt.Println("failed to store insights:", storeErr)
+		resp.StoreError = storeErr.Error()
+	}
+	RenumberSkippedCalls(&resp, input)
+	return input, resp, nil
+}
+
+// RenumberSkippedCalls names the call-level insights after the calls' positions in the request. The prompt
+// numbers the calls it was given, which differ once the skip policy dropped failed calls.
+func RenumberSkippedCalls(resp *ContentGenerationResponse, input ApiInputParams) {
+	for i := range resp.Locations {
+		insight := &resp.Locations[i]
+		insight.InsightType = requestInsightType(input, insight.InsightType)
+		if insight.Structured != nil {
+			insight.Structured.InsightType = requestInsightType(input, insight.Structured.InsightType)
+		}
+	}
+	for i := range resp.StructuredInsights {
+		resp.StructuredInsights[i].InsightType = requestInsightType(input, resp.StructuredInsights[i].InsightType)
+	}
+}
+
+// CallTranscript is the outcome of transcribing one call
+type CallTranscript struct {
+	Text string
+	Err  error
+}
+
+// PendingTranscriptionError is returned by TranscribeCalls when every failed call is still being
+// transcribed by the media service. It matches urlMedia.ErrTranscriptionProcessing; pass MediaIDs back
+// as ApiInputParams.PendingMediaIDs to resume polling instead of submitting the calls again.
+type PendingTranscriptionError struct {
+	MediaIDs map[int]string // by CallData index
+}
+
+func (e *PendingTranscriptionError) Error() string {
+	calls := make([]string, 0, len(e.MediaIDs))
+	for index, mediaID := range e.MediaIDs {
+		calls = append(calls, fmt.Sprintf("call %d (media %s)", index+1, mediaID))
+	}
+	sort.Strings(calls)
+	return "transcription still processing for " + strings.Join(calls, ", ") + ", retry later"
+}
+
+func (e *PendingTranscriptionError) Unwrap() error {
+	return urlMedia.ErrTranscriptionProcessing
+}
+
+// TranscribeCalls transcribes every call with at most TranscriptionConcurrency (or the configured
+// worker count) calls in flight. Results keep the order of CallData. With the fail_fast policy the
+// first failure cancels the remaining calls and is returned as the error; calls still processing at the
+// media service do not cancel the others and are returned together as a *PendingTranscriptionError.
+func TranscribeCalls(ctx context.Context, input ApiInputParams, progress ProgressFunc) ([]CallTranscript, error) {
+	policy := input.TranscriptionFailurePolicy
+	if policy == "" {
+		policy = FailurePolicyFailFast
+	}
+	if policy != FailurePolicyFailFast && policy != FailurePolicySkip && policy != FailurePolicyMark {
+		return nil, fmt.Errorf("unknown transcription_failure_policy %q", policy)
+	}
+	workers := callConcurrency(input)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	total := len(input.CallData)
+	results := make([]CallTranscript, total)
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	done := 0
+
+	progress(StageTranscribing, 0, total)
+	for index, callData := range input.CallData {
+		wg.Add(1)
+		go func(index int, callData CallData) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				results[index] = CallTranscript{Err: ctx.Err()}
+				return
+			}
+			if ctx.Err() != nil {
+				results[index] = CallTranscript{Err: ctx.Err()}
+				return
+			}
+
+			text, err := transcribeCall(ctx, input, index, callData)
+			results[index] = CallTranscript{Text: text, Err: err}
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if err != nil && firstErr == nil && !errors.Is(err, urlMedia.ErrTranscriptionProcessing) {
+				firstErr = fmt.Errorf("call %d: %w", index+1, err)
+				if policy == FailurePolicyFailFast {
+					cancel()
+				}
+			}
+			progress(StageTranscribing, done, total)
+		}(index, callData)
+	}
+	wg.Wait()
+
+	if policy == FailurePolicyFailFast {
+		if firstErr != nil {
+			return results, firstErr
+		}
+		// The parent context may have been cancelled without any call failing
+		if ctxErr := ctx.Err(); ctxErr != nil && done < total {
+			return results, ctxErr
+		}
+		if pending := pendingTranscriptions(results); pending != nil {
+			return results, pending
+		}
+	}
+	return results, nil
+}
+
+// callConcurrency is the number of calls of input processed at once: TranscriptionConcurrency, or the
+// configured worker count, at least one
+func callConcurrency(input ApiInputParams) int {
+	workers := input.TranscriptionConcurrency
+	if workers <= 0 {
+		workers = config.Get().Transcription.Workers
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	return workers
+}
+
+// pendingTranscriptions collects the MediaId of every call still processing, nil when there is none
+func pendingTranscriptions(results []CallTranscript) *PendingTranscriptionError {
+	var pending *PendingTranscriptionError
+	for index, result := range results {
+		var processingErr *urlMedia.ProcessingError
+		if !errors.As(result.Err, &processingErr) {
+			continue
+		}
+		if pending == nil {
+			pending = &PendingTranscriptionError{MediaIDs: map[int]string{}}
+		}
+		pending.MediaIDs[index] = processingErr.MediaID
+	}
+	return pending
+}
+
+// transcribeCall returns the transcript of one call, only calling the configured transcriber
+// when the call brings a recording URL or an uploaded audio file
+func transcribeCall(ctx context.Context, input ApiInputParams, index int, callData CallData) (string, error) {
+	switch callData.Source() {
+	case SourceTranscript:
+		return callData.Transcript, nil
+	case SourceTranscriptURL:
+		text, textErr := urlMedia.GetTextFromURL(callData.TranscriptURL)
+		if textErr != nil {
+			return "", fmt.Errorf("GettextFrom url failed: %w", textErr)
+		}
+		return text, nil
+	case SourceAudioFile:
+		if len(callData.Audio) == 0 {
+			return "", fmt.Errorf("no audio uploaded for %q", callData.AudioFile)
+		}
+	case SourceRecordingURL:
+	default:
+		return "", errors.New("call has no recording, transcript or audio")
+	}
+
+	transcriber, err := transcription.Get("")
+	if err != nil {
+		return "", err
+	}
+	return transcriber.Transcribe(ctx, transcription.Request{
+		RecordingURL: callData.CallRecordingURL,
+		Audio:        callData.Audio,
+		FileName:     callData.AudioFileName,
+		CallType:     callData.CallType,
+		ExecutiveID:  input.ExecutiveID,
+		Glid:         fmt.Sprint(input.Glid),
+		MediaID:      input.PendingMediaIDs[index],
+	})
+}
+
+// applyTranscriptionPolicy fills TrascriptionURLTxt, dropping or marking failed calls
+func applyTranscriptionPolicy(input ApiInputParams, transcripts []CallTranscript) ApiInputParams {
+	callData := make([]CallData, 0, len(transcripts))
+	texts := make([]string, 0, len(transcripts))
+	for index, transcript := range transcripts {
+		if transcript.Err != nil {
+			globalFunctions.WriteJsonLogs(nil, "transcription_failures", map[string]any{
+				"glid":         input.Glid,
+				"executive_id": input.ExecutiveID,
+				"call_index":   index + 1,
+				"policy":       input.TranscriptionFailurePolicy,
+				"error":        transcript.Err.Error(),
+			})
+			if input.TranscriptionFailurePolicy == FailurePolicySkip {
+				continue
+			}
+			transcript.Text = TranscriptionFailedText
+		}
+		call := input.CallData[index]
+		call.RequestIndex = index + 1
+		callData = append(callData, call)
+		texts = append(texts, transcript.Text)
+	}
+	input.CallData = callData
+	input.TrascriptionURLTxt = texts
+	input.Transcripts = ParseTranscripts(texts)
+	input.CallMetrics = ComputeCallMetrics(input.Transcripts)
+	input.Languages = DetectLanguages(texts)
+	return input
+}
+
+// ParseTranscripts splits each transcript into executive/seller turns, falling back to the
+// raw text as one utterance when the format is not recognised
+func ParseTranscripts(texts []string) []transcript.Transcript {
+	transcripts := make([]transcript.Transcript, 0, len(texts))
+	for _, text := range texts {
+		transcripts = append(transcripts, transcript.ParseOrPlain(text))
+	}
+	return transcripts
+}
+
+// RedactForLLM returns a copy of input whose transcripts, sample calls and seller history have their
+// personal data replaced by placeholders, with the Redactor holding the mapping. When the llm destination
+// is not redacted input is returned as is with a nil Redactor.
+func RedactForLLM(input ApiInputParams) (ApiInputParams, *redaction.Redactor) {
+	if !redaction.Enabled(redaction.DestinationLLM) {
+		return input, nil
+	}
+	redactor := redaction.NewRedactor()
+
+	texts := make([]string, len(input.TrascriptionURLTxt))
+	for i, text := range input.TrascriptionURLTxt {
+		texts[i] = redactor.Redact(text)
+	}
+	transcripts := make([]transcript.Transcript, len(input.Transcripts))
+	for i, parsed := range input.Transcripts {
+		utterances := make([]transcript.Utterance, len(parsed.Utterances))
+		for j, utterance := range parsed.Utterances {
+			utterance.Text = redactor.Redact(utterance.Text)
+			utterances[j] = utterance
+		}
+		parsed.Utterances = utterances
+		transcripts[i] = parsed
+	}
+
+	input.TrascriptionURLTxt = texts
+	input.Transcripts = transcripts
+	input.SampleCalls = redactor.Redact(input.SampleCalls)
+	history := make([]PriorCall, len(input.History))
+	for i, call := range input.History {
+		call.Summary = redactor.Redact(call.Summary)
+		history[i] = call
+	}
+	input.History = history
+	return input, redactor
+}
+
+// callLanguageCode returns the detected language of call i for the logs
+func callLanguageCode(languages []language.Detection, i int) string {
+	if i >= len(languages) {
+		return ""
+	}
+	return languages[i].Code
+}
+
+// transcriptFormat names the parsed format of call i for the logs
+func transcriptFormat(transcripts []transcript.Transcript, i int) string {
+	if i >= len(transcripts) {
+		return ""
+	}
+	if !transcripts[i].Diarized() {
+		return "raw"
+	}
+	return transcripts[i].Format
+}
+
+// CallLevelInsights drops the aggregated block, keeping one insight per call
+func CallLevelInsights(insights []Insights, callCount int) []Insights {
+	callLevel := make([]Insights, 0, len(insights))
+	for _, insight := range insights {
+		if CallIndexOf(insight.InsightType, callCount) != 0 {
+			callLevel = append(callLevel, insight)
+		}
+	}
+	return callLevel
+}
@@ -0,0 +1,150 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/insightStore"
+	transcription "voice-hack-backend/utilities/transcriptionService"
+)
+
+// useFakeTranscriber makes a fake transcriber serving transcripts (file name to text) the default one
+func useFakeTranscriber(t *testing.T, transcripts map[string]string) *transcription.FakeTranscriber {
+	t.Helper()
+	dir := t.TempDir()
+	for name, text := range transcripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fake := transcription.NewFakeTranscriber(dir)
+	transcription.Register(fake)
+	transcription.SetDefault(transcription.ProviderFake)
+	t.Cleanup(func() { transcription.Configure(config.Get().Transcription) })
+	return fake
+}
+
+func recordedCalls(urls ...string) ApiInputParams {
+	input := ApiInputParams{Glid: 42, ExecutiveID: "E1"}
+	for _, url := range urls {
+		input.CallData = append(input.CallData, CallData{CallRecordingURL: url, CallType: "PNS"})
+	}
+	return input
+}
+
+func TestTranscribeCallsKeepsCallOrder(t *testing.T) {
+	fake := useFakeTranscriber(t, map[string]string{"a.txt": "Seller: first", "b.txt": "Seller: second"})
+	input := recordedCalls("https://recordings.example.com/a.mp3", "https://recordings.example.com/b.mp3")
+	input.CallData = append(input.CallData, CallData{Transcript: "Seller: pasted"})
+	input.TranscriptionConcurrency = 2
+
+	results, err := TranscribeCalls(context.Background(), input, func(string, int, int) {})
+	if err != nil {
+		t.Fatalf("TranscribeCalls: %v", err)
+	}
+	for i, want := range []string{"Seller: first", "Seller: second", "Seller: pasted"} {
+		if results[i].Text != want || results[i].Err != nil {
+			t.Errorf("call %d = %+v, want %q", i+1, results[i], want)
+		}
+	}
+	requests := fake.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("transcriber got %d requests, want only the two recordings", len(requests))
+	}
+	if requests[0].ExecutiveID != "E1" || requests[0].Glid != "42" || requests[0].CallType != "PNS" {
+		t.Errorf("request = %+v, want the executive, seller and call type", requests[0])
+	}
+}
+
+func TestTranscribeCallsFailurePolicies(t *testing.T) {
+	useFakeTranscriber(t, map[string]string{"a.txt": "Seller: first"})
+
+	input := recordedCalls("https://recordings.example.com/a.mp3", "https://recordings.example.com/missing.mp3")
+	if _, err := TranscribeCalls(context.Background(), input, func(string, int, int) {}); err == nil {
+		t.Fatal("fail_fast TranscribeCalls succeeded with a call that has no transcript")
+	}
+
+	for _, tc := range []struct {
+		policy string
+		want   []string
+	}{
+		{FailurePolicySkip, []string{"Seller: first"}},
+		{FailurePolicyMark, []string{"Seller: first", TranscriptionFailedText}},
+	} {
+		input.TranscriptionFailurePolicy = tc.policy
+		results, err := TranscribeCalls(context.Background(), input, func(string, int, int) {})
+		if err != nil {
+			t.Fatalf("%s: TranscribeCalls: %v", tc.policy, err)
+		}
+		applied := applyTranscriptionPolicy(input, results)
+		if len(applied.TrascriptionURLTxt) != len(tc.want) || len(applied.CallData) != len(tc.want) {
+			t.Fatalf("%s: transcripts = %q", tc.policy, applied.TrascriptionURLTxt)
+		}
+		for i, want := range tc.want {
+			if applied.TrascriptionURLTxt[i] != want {
+				t.Errorf("%s: transcript %d = %q, want %q", tc.policy, i+1, applied.TrascriptionURLTxt[i], want)
+			}
+			if applied.CallData[i].RequestIndex != i+1 {
+				t.Errorf("%s: call %d has request index %d", tc.policy, i+1, applied.CallData[i].RequestIndex)
+			}
+		}
+	}
+}
+
+func TestSkippedCallsKeepTheirRequestIndex(t *testing.T) {
+	store, err := insightStore.OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "insights.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	insightStore.SetDefault(store)
+
+	// The first of three calls failed and was skipped, the prompt numbered the other two call_1 and call_2
+	input := recordedCalls("https://recordings.example.com/a.mp3", "https://recordings.example.com/b.mp3", "https://recordings.example.com/c.mp3")
+	input.TranscriptionFailurePolicy = FailurePolicySkip
+	input = applyTranscriptionPolicy(input, []CallTranscript{
+		{Err: errors.New("recording not found")}, {Text: "Seller: second"}, {Text: "Seller: third"},
+	})
+	resp := ContentGenerationResponse{
+		Locations: []Insights{
+			{InsightType: "call_1", Structured: &InsightsV2{InsightType: "call_1"}},
+			{InsightType: "call_2", Structured: &InsightsV2{InsightType: "call_2"}},
+			{InsightType: "final"},
+		},
+		StructuredInsights: []InsightsV2{{InsightType: "call_1"}, {InsightType: "call_2"}, {InsightType: "final"}},
+	}
+
+	if _, err := StoreInsights(context.Background(), input, resp); err != nil {
+		t.Fatalf("StoreInsights: %v", err)
+	}
+	rows, err := store.ListInsights(context.Background(), insightStore.InsightFilter{Glids: []int{42}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := map[int]string{}
+	for _, row := range rows {
+		stored[row.CallIndex] = row.InsightType + " " + row.SourceURL
+		if !strings.Contains(row.StructuredJSON, `"insight_type":"`+row.InsightType+`"`) {
+			t.Errorf("call %d stored structured insight %s", row.CallIndex, row.StructuredJSON)
+		}
+	}
+	want := map[int]string{2: "call_2 https://recordings.example.com/b.mp3", 3: "call_3 https://recordings.example.com/c.mp3"}
+	if !reflect.DeepEqual(stored, want) {
+		t.Errorf("stored calls = %v, want %v", stored, want)
+	}
+
+	RenumberSkippedCalls(&resp, input)
+	for i, wantType := range []string{"call_2", "call_3", "final"} {
+		if resp.Locations[i].InsightType != wantType || resp.StructuredInsights[i].InsightType != wantType {
+			t.Errorf("insight %d = %q / %q, want %q", i+1, resp.Locations[i].InsightType, resp.StructuredInsights[i].InsightType, wantType)
+		}
+		if resp.Locations[i].Structured != nil && resp.Locations[i].Structured.InsightType != wantType {
+			t.Errorf("insight %d structured type = %q, want %q", i+1, resp.Locations[i].Structured.InsightType, wantType)
+		}
+	}
+}
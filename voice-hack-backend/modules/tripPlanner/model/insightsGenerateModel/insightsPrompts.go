@@ -0,0 +1,189 @@
+package insightsGenerateModel
+
+import (
+	"encoding/json"
+	"strings"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/insightStore"
+	"voice-hack-backend/utilities/language"
+)
+
+// SystemPromptData is rendered by the system prompt template
+type SystemPromptData struct {
+	Input              ApiInputParams
+	MultiCall          bool
+	SchemaV2           bool
+	HoldAlertSeconds   float64 // metrics.hold_alert
+	OutputLanguage     string  // language code of the insight text
+	OutputLanguageName string
+	Concerns           []insightStore.ConcernCategory // knowledge base, rendered as the resolution matrix
+	ConcernCodes       []string
+	OwnerPersonas      []string
+	AlertTypes         []string
+	AlertSeverities    []string
+	Sentiments         []string
+	Example            string // JSON example of the response in the request's schema version
+	SampleCalls        string
+}
+
+// NewSystemPromptData collects the values the system prompt template needs from input and the config
+func NewSystemPromptData(input ApiInputParams) SystemPromptData {
+	data := SystemPromptData{
+		Input:              input,
+		MultiCall:          len(input.CallData) > 1,
+		SchemaV2:           input.SchemaVersion == SchemaV2,
+		HoldAlertSeconds:   config.Get().Metrics.HoldAlert.Seconds(),
+		OutputLanguage:     OutputLanguage(input),
+		OutputLanguageName: language.Name(OutputLanguage(input)),
+		Concerns:           KnowledgeBaseConcerns(),
+		ConcernCodes:       ConcernCodes(),
+		OwnerPersonas:      ownerPersonaValues,
+		AlertTypes:         alertTypeValues,
+		AlertSeverities:    alertSeverityValues,
+		Sentiments:         sentimentValues,
+		Example:            insightsV1Example(),
+		SampleCalls:        input.SampleCalls,
+	}
+	if data.SchemaV2 {
+		exampleBytes, _ := json.MarshalIndent(insightsV2Example(), "", "  ")
+		data.Example = string(exampleBytes)
+	}
+	return data
+}
+
+// UserPromptData is rendered by the user prompt template
+type UserPromptData struct {
+	Input     ApiInputParams
+	MultiCall bool
+	SchemaV2  bool
+	Calls     []UserPromptCall
+	History   []PriorCall // seller's earlier calls, most recent first
+}
+
+// UserPromptCall is one call of the user prompt
+type UserPromptCall struct {
+	Number        int
+	CallType      string
+	CallDate      string
+	LanguageLine  string // "- Language: ..." line, empty when the language is unknown
+	MetricsLines  string // measured metrics and backend alerts, empty without a timestamped transcript
+	SpeakerTurns  bool   // Transcript holds one "[mm:ss] Executive: ..." line per turn
+	HasTranscript bool
+	Transcript    string
+}
+
+// NewUserPromptData collects the calls of input with their transcripts, languages and measured metrics
+func NewUserPromptData(input ApiInputParams) UserPromptData {
+	data := UserPromptData{
+		Input:     input,
+		MultiCall: len(input.CallData) > 1,
+		SchemaV2:  input.SchemaVersion == SchemaV2,
+		Calls:     make([]UserPromptCall, 0, len(input.CallData)),
+		History:   input.History,
+	}
+	for i, callData := range input.CallData {
+		call := UserPromptCall{
+			Number:       i + 1,
+			CallType:     callData.CallType,
+			CallDate:     callData.CallDate,
+			LanguageLine: callLanguagePromptText(input, i),
+		}
+		if len(input.CallMetrics) > i && input.CallMetrics[i] != nil {
+			metrics := *input.CallMetrics[i]
+			call.MetricsLines = metricsPromptText(metrics, MetricAlerts(metrics, config.Get().Metrics))
+		}
+		if len(input.Transcripts) > i && input.Transcripts[i].Diarized() {
+			call.SpeakerTurns, call.HasTranscript = true, true
+			call.Transcript = strings.TrimSuffix(input.Transcripts[i].PromptText(), "\n")
+		} else if len(input.TrascriptionURLTxt) > i {
+			call.HasTranscript = true
+			call.Transcript = input.TrascriptionURLTxt[i]
+		}
+		data.Calls = append(data.Calls, call)
+	}
+	return data
+}
+
+// CompliancePromptData is rendered by the compliance prompt template for one call
+type CompliancePromptData struct {
+	Input              ApiInputParams
+	CallNumber         int
+	SpeakerTurns       bool   // Transcript holds one line per speaker turn
+	Transcript         string // sent as the user prompt
+	OutputLanguage     string // language of the evidence
+	OutputLanguageName string
+	Steps              []CompliancePromptStep
+}
+
+// CompliancePromptStep is one numbered step of the compliance prompt
+type CompliancePromptStep struct {
+	ID           int
+	ConcernLabel string
+	ComplianceStep
+}
+
+// NewCompliancePromptData numbers the steps of call callIndex (1-based) and picks its transcript
+func NewCompliancePromptData(input ApiInputParams, callIndex int, steps []ComplianceStep) CompliancePromptData {
+	data := CompliancePromptData{
+		Input:              input,
+		CallNumber:         callIndex,
+		Transcript:         input.TrascriptionURLTxt[callIndex-1],
+		OutputLanguage:     OutputLanguage(input),
+		OutputLanguageName: language.Name(OutputLanguage(input)),
+		Steps:              make([]CompliancePromptStep, 0, len(steps)),
+	}
+	if len(input.Transcripts) >= callIndex && input.Transcripts[callIndex-1].Diarized() {
+		data.SpeakerTurns = true
+		data.Transcript = strings.TrimSuffix(input.Transcripts[callIndex-1].PromptText(), "\n")
+	}
+	for i, step := range steps {
+		data.Steps = append(data.Steps, CompliancePromptStep{ID: i + 1, ConcernLabel: ConcernLabel(step.Concern), ComplianceStep: step})
+	}
+	return data
+}
+
+// FinalSummaryPromptData is rendered by the final summary prompt template
+type FinalSummaryPromptData struct {
+	Input              ApiInputParams
+	Insights           []Insights // at most MaxCallLimit when set
+	Statistics         string     // FormatStatistics of the stored insights
+	OutputLanguage     string
+	OutputLanguageName string
+	Example            string
+}
+
+// NewFinalSummaryPromptData collects the stored insights and their exact statistics for the final summary prompt
+func NewFinalSummaryPromptData(allInsights []Insights, input ApiInputParams, stats *InsightStatistics) FinalSummaryPromptData {
+	if input.MaxCallLimit > 0 && len(allInsights) > input.MaxCallLimit {
+		allInsights = allInsights[:input.MaxCallLimit]
+	}
+	return FinalSummaryPromptData{
+		Input:              input,
+		Insights:           allInsights,
+		Statistics:         FormatStatistics(stats),
+		OutputLanguage:     OutputLanguage(input),
+		OutputLanguageName: language.Name(OutputLanguage(input)),
+		Example:            finalInsightsExample(),
+	}
+}
+
+// finalInsightsExample is the quantitative JSON example shown in the final summary prompt. Numbers are
+// placeholders so the model copies them from EXACT STATISTICS instead of from the example.
+func finalInsightsExample() string {
+	example := Insights{
+		InsightType: "final",
+		Concerns:    "<Concern category> (<percent from EXACT STATISTICS>% of calls); <Concern category> (<percent from EXACT STATISTICS>% of calls)",
+		Resolution:  "<Systemic failure or best practice> (<count from EXACT STATISTICS> calls); <Guidance still missing>",
+		NextSteps:   "Executive: <action>; Category Manager: <action>; Product Manager: <action>",
+		Alert:       "<Alert category> (<percent from EXACT STATISTICS>% of calls) for <person/team>; <Alert category> (<percent from EXACT STATISTICS>% of calls) for <person/team>",
+		Sentiment:   "<Sentiment> (<percent from EXACT STATISTICS>%) -> <Sentiment> (<percent from EXACT STATISTICS>%)",
+		KeyPoints:   "<Keyword> (<count from EXACT STATISTICS>), <Keyword> (<count from EXACT STATISTICS>)",
+	}
+	// Encode without HTML escaping so the placeholders are not sent as \u003c...\u003e
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(example)
+	return strings.TrimSuffix(b.String(), "\n")
+}
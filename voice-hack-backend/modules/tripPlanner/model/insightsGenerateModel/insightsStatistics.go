@@ -0,0 +1,333 @@
+package insightsGenerateModel
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// CountShare is one bucket of a distribution; Percent is relative to the number of calls
+type CountShare struct {
+	Label   string  `json:"label"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// InsightStatistics are computed in Go from call-level insights so the numbers never come from the LLM
+type InsightStatistics struct {
+	TotalCalls     int            `json:"total_calls"`
+	SentimentStart []CountShare   `json:"sentiment_start"` // sentiment at the start of the call
+	SentimentEnd   []CountShare   `json:"sentiment_end"`   // sentiment at the end of the call
+	SentimentShift map[string]int `json:"sentiment_shift"` // improved / unchanged / worsened / unknown
+	Alerts         []CountShare   `json:"alerts"`          // calls raising each alert category
+	Concerns       []CountShare   `json:"concerns"`        // calls mentioning each concern category
+	Keywords       []CountShare   `json:"keywords"`        // occurrences of each KeyPoints keyword
+}
+
+const (
+	SentimentPositive = "Positive"
+	SentimentNeutral  = "Neutral"
+	SentimentNegative = "Negative"
+	SentimentAngry    = "Angry"
+	SentimentUnknown  = "Unknown"
+)
+
+var sentimentScore = map[string]int{
+	SentimentAngry:    -2,
+	SentimentNegative: -1,
+	SentimentNeutral:  0,
+	SentimentPositive: 1,
+}
+
+// Alert categories from the ALERT CATEGORIES section of the system prompt
+const (
+	AlertChurnRisk             = "Competitor/Churn Risk"
+	AlertUpsellOpportunity     = "Upsell Opportunity"
+	AlertExecutiveInefficiency = "Executive Inefficiency"
+	AlertProcessFailure        = "Internal Process Failure"
+	AlertNone                  = "None"
+	AlertOther                 = "Other"
+)
+
+// ConcernOther counts concerns that match none of the known categories
+const ConcernOther = "Other"
+
+type keywordCategory struct {
+	Name     string
+	Keywords []string
+}
+
+// alertKeywords classify free-text alerts, first match wins
+var alertKeywords = []keywordCategory{
+	{AlertChurnRisk, []string{"churn", "competitor", "leave", "cancel", "discontinue", "not continue", "dissatisf", "threat"}},
+	{AlertExecutiveInefficiency, []string{"inefficien", "rude", "unprofessional", "hold time", "false info", "wrong info", "no clear next", "abusive", "misconduct"}},
+	{AlertProcessFailure, []string{"process failure", "bounced", "conflicting", "internal process"}},
+	{AlertUpsellOpportunity, []string{"upsell", "upgrade", "higher package", "higher plan", "more leads", "more buyleads"}},
+}
+
+// concernKeywords returns the keyword table of the knowledge base concern categories
+func concernKeywords() []keywordCategory {
+	concernCategories := ConcernCategories()
+	categories := make([]keywordCategory, 0, len(concernCategories))
+	for _, category := range concernCategories {
+		if len(category.Keywords) > 0 {
+			categories = append(categories, keywordCategory{category.Label, category.Keywords})
+		}
+	}
+	return categories
+}
+
+// ComputeStatistics builds the exact distributions for a set of call-level insights.
+// Typed v2 insights are counted from their enums, v1 text is classified by keywords.
+func ComputeStatistics(insights []Insights) *InsightStatistics {
+	stats := &InsightStatistics{
+		TotalCalls:     len(insights),
+		SentimentShift: map[string]int{},
+	}
+	startCounts := map[string]int{}
+	endCounts := map[string]int{}
+	alertCounts := map[string]int{}
+	concernCounts := map[string]int{}
+	keywordCounts := map[string]int{}
+	keywordLabels := map[string]string{}
+
+	for _, insight := range insights {
+		start, end := callSentiment(insight)
+		alerts := callAlertLabels(insight)
+		concerns := callConcernLabels(insight)
+		keywords := SplitKeyPoints(insight.KeyPoints)
+		if structured := insight.Structured; structured != nil {
+			keywords = structured.KeyPoints
+		}
+
+		startCounts[start]++
+		endCounts[end]++
+		stats.SentimentShift[sentimentShift(start, end)]++
+
+		for _, category := range alerts {
+			alertCounts[category]++
+		}
+		for _, category := range concerns {
+			concernCounts[category]++
+		}
+		for _, keyword := range keywords {
+			key := strings.ToLower(keyword)
+			if _, seen := keywordLabels[key]; !seen {
+				keywordLabels[key] = keyword
+			}
+			keywordCounts[key]++
+		}
+	}
+
+	stats.SentimentStart = toShares(startCounts, nil, len(insights))
+	stats.SentimentEnd = toShares(endCounts, nil, len(insights))
+	stats.Alerts = toShares(alertCounts, nil, len(insights))
+	stats.Concerns = toShares(concernCounts, nil, len(insights))
+	stats.Keywords = toShares(keywordCounts, keywordLabels, len(insights))
+	return stats
+}
+
+// callSentiment returns the normalized start and end sentiment of a call, from the typed insight when present
+func callSentiment(insight Insights) (start string, end string) {
+	if structured := insight.Structured; structured != nil {
+		return normalizeSentiment(structured.Sentiment.Start), normalizeSentiment(structured.Sentiment.End)
+	}
+	return ParseSentiment(insight.Sentiment)
+}
+
+// callAlertLabels returns the alert categories of a call, from the typed alerts when present
+func callAlertLabels(insight Insights) []string {
+	if insight.Structured != nil {
+		return structuredAlertLabels(insight.Structured.Alerts)
+	}
+	return ClassifyAlert(insight.Alert)
+}
+
+// callConcernLabels returns the concern categories of a call, from the typed concerns when present
+func callConcernLabels(insight Insights) []string {
+	if insight.Structured != nil {
+		return structuredConcernLabels(insight.Structured.Concerns)
+	}
+	return ClassifyConcerns(insight.Concerns)
+}
+
+// ParseSentiment reads "Negative -> Neutral" style values; a single value is both start and end
+func ParseSentiment(raw string) (start string, end string) {
+	normalized := strings.NewReplacer("→", "->", "=>", "->").Replace(raw)
+	parts := strings.Split(normalized, "->")
+	start = normalizeSentiment(parts[0])
+	end = normalizeSentiment(parts[len(parts)-1])
+	return start, end
+}
+
+func normalizeSentiment(raw string) string {
+	lower := strings.ToLower(raw)
+	switch {
+	case strings.Contains(lower, "angry"), strings.Contains(lower, "frustrat"):
+		return SentimentAngry
+	case strings.Contains(lower, "negative"):
+		return SentimentNegative
+	case strings.Contains(lower, "positive"):
+		return SentimentPositive
+	case strings.Contains(lower, "neutral"):
+		return SentimentNeutral
+	}
+	return SentimentUnknown
+}
+
+func sentimentShift(start string, end string) string {
+	startScore, startOk := sentimentScore[start]
+	endScore, endOk := sentimentScore[end]
+	switch {
+	case !startOk || !endOk:
+		return "unknown"
+	case endScore > startScore:
+		return "improved"
+	case endScore < startScore:
+		return "worsened"
+	}
+	return "unchanged"
+}
+
+// ClassifyAlert returns the distinct alert categories mentioned in an alert text
+func ClassifyAlert(alert string) []string {
+	trimmed := strings.ToLower(strings.TrimSpace(alert))
+	if trimmed == "" || trimmed == "none" || trimmed == "n/a" || trimmed == "na" || strings.HasPrefix(trimmed, "no alert") {
+		return []string{AlertNone}
+	}
+	categories := classify(alert, alertKeywords, "")
+	if len(categories) == 0 {
+		return []string{AlertOther}
+	}
+	return categories
+}
+
+// ClassifyConcerns returns the distinct concern categories mentioned in a concerns text, with
+// ConcernOther for every concern that matches none of them
+func ClassifyConcerns(concerns string) []string {
+	return classify(concerns, concernKeywords(), ConcernOther)
+}
+
+func structuredAlertLabels(alerts []AlertV2) []string {
+	if len(alerts) == 0 {
+		return []string{AlertNone}
+	}
+	return distinct(alerts, func(alert AlertV2) string { return alertTypeLabels[alert.Type] })
+}
+
+func structuredConcernLabels(concerns []ConcernV2) []string {
+	return distinct(concerns, func(concern ConcernV2) string { return ConcernLabel(concern.Category) })
+}
+
+func distinct[T any](items []T, label func(T) string) []string {
+	seen := map[string]bool{}
+	var labels []string
+	for _, item := range items {
+		if value := label(item); !seen[value] {
+			seen[value] = true
+			labels = append(labels, value)
+		}
+	}
+	return labels
+}
+
+// classify splits text into ";"-separated items and maps each to the first matching category. Items
+// matching no category are counted as other, or dropped when other is empty.
+func classify(text string, categories []keywordCategory, other string) []string {
+	seen := map[string]bool{}
+	var matched []string
+	for _, item := range strings.Split(text, ";") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		lower := strings.ToLower(item) + " "
+		name := other
+		for _, category := range categories {
+			if containsAny(lower, category.Keywords) {
+				name = category.Name
+				break
+			}
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			matched = append(matched, name)
+		}
+	}
+	return matched
+}
+
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitKeyPoints splits "Buy leads, Catalog; Filter" into trimmed keywords
+func SplitKeyPoints(keyPoints string) []string {
+	var keywords []string
+	for _, keyword := range strings.FieldsFunc(keyPoints, func(r rune) bool { return r == ',' || r == ';' || r == '\n' }) {
+		keyword = strings.TrimSpace(strings.TrimLeft(keyword, "-•* "))
+		if keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords
+}
+
+// toShares sorts buckets by count (then label) and adds the percentage of total calls
+func toShares(counts map[string]int, labels map[string]string, total int) []CountShare {
+	shares := make([]CountShare, 0, len(counts))
+	for key, count := range counts {
+		label := key
+		if labels != nil {
+			label = labels[key]
+		}
+		percent := 0.0
+		if total > 0 {
+			percent = math.Round(float64(count)*1000/float64(total)) / 10
+		}
+		shares = append(shares, CountShare{Label: label, Count: count, Percent: percent})
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Count != shares[j].Count {
+			return shares[i].Count > shares[j].Count
+		}
+		return shares[i].Label < shares[j].Label
+	})
+	return shares
+}
+
+// FormatStatistics renders the statistics as prompt lines the LLM must quote verbatim
+func FormatStatistics(stats *InsightStatistics) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Total calls: %d\n", stats.TotalCalls))
+	writeShares := func(title string, shares []CountShare) {
+		b.WriteString(title + ": ")
+		parts := make([]string, 0, len(shares))
+		for _, share := range shares {
+			parts = append(parts, fmt.Sprintf("%s %d/%d (%.1f%%)", share.Label, share.Count, stats.TotalCalls, share.Percent))
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "none")
+		}
+		b.WriteString(strings.Join(parts, "; ") + "\n")
+	}
+	writeShares("Sentiment at call start", stats.SentimentStart)
+	writeShares("Sentiment at call end", stats.SentimentEnd)
+	b.WriteString(fmt.Sprintf("Sentiment shift: improved %d, unchanged %d, worsened %d, unknown %d\n",
+		stats.SentimentShift["improved"], stats.SentimentShift["unchanged"], stats.SentimentShift["worsened"], stats.SentimentShift["unknown"]))
+	writeShares("Alerts (calls)", stats.Alerts)
+	writeShares("Concern categories (calls)", stats.Concerns)
+
+	b.WriteString("Keywords (occurrences): ")
+	keywords := make([]string, 0, len(stats.Keywords))
+	for _, share := range stats.Keywords {
+		keywords = append(keywords, fmt.Sprintf("%s (%d)", share.Label, share.Count))
+	}
+	b.WriteString(strings.Join(keywords, ", ") + "\n")
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"voice-hack-backend/utilities/insightStore"
+)
+
+// useSeededConcerns loads the concern categories seeded in a fresh store
+func useSeededConcerns(t *testing.T) {
+	t.Helper()
+	store, err := insightStore.OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "insights.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	insightStore.SetDefault(store)
+
+	previous := KnowledgeBaseConcerns()
+	if err := LoadConcernCategories(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		knowledgeBaseMu.Lock()
+		knowledgeBase = previous
+		knowledgeBaseMu.Unlock()
+	})
+}
+
+func TestClassifyConcernsCountsUnmatchedItemsAsOther(t *testing.T) {
+	useSeededConcerns(t)
+
+	for _, tc := range []struct {
+		concerns string
+		want     []string
+	}{
+		{"Irrelevant buyleads; asks about the trade fair dates", []string{"Irrelevant Buyleads", ConcernOther}},
+		{"Asks about the trade fair dates; wants a new sales contact", []string{ConcernOther}},
+		{"Irrelevant buyleads; ; update product images", []string{"Irrelevant Buyleads", "Catalogue Update"}},
+		{"", nil},
+	} {
+		if got := ClassifyConcerns(tc.concerns); strings.Join(got, "|") != strings.Join(tc.want, "|") {
+			t.Errorf("ClassifyConcerns(%q) = %q, want %q", tc.concerns, got, tc.want)
+		}
+	}
+}
+
+func TestComputeStatisticsCountsOtherConcerns(t *testing.T) {
+	useSeededConcerns(t)
+
+	stats := ComputeStatistics([]Insights{
+		{InsightType: "call_1", Concerns: "Irrelevant buyleads; asks about the trade fair dates"},
+		{InsightType: "call_2", Concerns: "Irrelevant buyleads"},
+	})
+	counts := map[string]int{}
+	for _, share := range stats.Concerns {
+		counts[share.Label] = share.Count
+	}
+	if counts["Irrelevant Buyleads"] != 2 || counts[ConcernOther] != 1 {
+		t.Errorf("concern counts = %v, want 2 Irrelevant Buyleads and 1 Other", counts)
+	}
+}
+
+func TestFinalInsightsExampleHasNoNumbers(t *testing.T) {
+	example := finalInsightsExample()
+	if strings.ContainsAny(example, "0123456789") {
+		t.Errorf("the final summary example shows numbers the model could copy:\n%s", example)
+	}
+	if !strings.Contains(example, "EXACT STATISTICS") {
+		t.Errorf("the final summary example does not point to EXACT STATISTICS:\n%s", example)
+	}
+}
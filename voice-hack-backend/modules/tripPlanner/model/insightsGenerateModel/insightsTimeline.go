@@ -0,0 +1,274 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"voice-hack-backend/utilities/insightStore"
+)
+
+// Next step statuses of the seller timeline, taken from the stored action item of the step when there is
+// one and guessed from the later calls otherwise
+const (
+	NextStepOpen     = "open"     // action item open; without one, latest call or one of its concerns came back later
+	NextStepResolved = "resolved" // action item done; without one, a later call happened and none of its concerns came back
+)
+
+// SellerQuery holds the filters of the /sellers endpoints
+type SellerQuery struct {
+	Glid     int    `form:"-"`         // from the path
+	FromDate string `form:"from_date"` // first call date, YYYY-MM-DD
+	ToDate   string `form:"to_date"`   // last call date, YYYY-MM-DD
+	LastDays int    `form:"last_days"` // only calls of the last N days
+}
+
+// Input filters the stored insights down to the calls of the seller in the queried period
+func (query SellerQuery) Input() ApiInputParams {
+	return ApiInputParams{Glid: query.Glid, FromDate: query.FromDate, ToDate: query.ToDate, LastDays: query.LastDays}
+}
+
+// SellerApiResponse is returned by the /sellers endpoints
+type SellerApiResponse struct {
+	ApiStatus
+	Timeline *SellerTimeline `json:"timeline,omitempty"`
+}
+
+// SellerTimeline is the history of one seller across its stored calls, oldest call first
+type SellerTimeline struct {
+	Glid                int                 `json:"glid"`
+	Calls               int                 `json:"calls"`
+	FirstCallDate       string              `json:"first_call_date,omitempty"`
+	LastCallDate        string              `json:"last_call_date,omitempty"`
+	SentimentTrend      string              `json:"sentiment_trend"` // start of the first call to the end of the last one: improved, unchanged, worsened or unknown
+	SentimentTrajectory []SentimentPoint    `json:"sentiment_trajectory"`
+	RecurringConcerns   []RecurringConcern  `json:"recurring_concerns"` // concern categories raised on two calls or more
+	NextSteps           []TimelineNextStep  `json:"next_steps"`
+	OpenNextSteps       int                 `json:"open_next_steps"`
+	ResolvedNextSteps   int                 `json:"resolved_next_steps"`
+	Executives          []TimelineExecutive `json:"executives"` // by first call
+	Entries             []TimelineEntry     `json:"entries"`
+}
+
+// SentimentPoint is the sentiment of one call
+type SentimentPoint struct {
+	InsightID int64  `json:"insight_id"`
+	CallDate  string `json:"call_date"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	Shift     string `json:"shift"` // improved, unchanged, worsened or unknown
+}
+
+// RecurringConcern is a concern category raised on several calls of the seller
+type RecurringConcern struct {
+	Concern   string `json:"concern"` // category label
+	Calls     int    `json:"calls"`
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+}
+
+// TimelineNextStep is one next step agreed on a call
+type TimelineNextStep struct {
+	InsightID int64  `json:"insight_id"`
+	CallDate  string `json:"call_date"`
+	Owner     string `json:"owner,omitempty"` // persona, typed insights only
+	Action    string `json:"action"`
+	Due       string `json:"due,omitempty"`
+	Status    string `json:"status"` // NextStepOpen or NextStepResolved
+
+	ActionItemID int64 `json:"action_item_id,omitempty"` // stored action item the status comes from
+}
+
+// TimelineExecutive is one executive who handled calls of the seller
+type TimelineExecutive struct {
+	ExecutiveID   string `json:"executive_id"`
+	Calls         int    `json:"calls"`
+	FirstCallDate string `json:"first_call_date,omitempty"`
+	LastCallDate  string `json:"last_call_date,omitempty"`
+}
+
+// TimelineEntry is one stored call-level insight of the seller
+type TimelineEntry struct {
+	InsightID   int64       `json:"insight_id"`
+	CallDate    string      `json:"call_date"` // YYYY-MM-DD, the storage date when the call date was unknown
+	CallType    string      `json:"call_type"`
+	ExecutiveID string      `json:"executive_id"`
+	SourceURL   string      `json:"source_url,omitempty"`
+	Concerns    []string    `json:"concerns"` // category labels
+	Insight     Insights    `json:"insight"`
+	InsightV2   *InsightsV2 `json:"insight_v2,omitempty"` // when generated with schema v2
+}
+
+// GetSellerTimeline builds the timeline of query.Glid from the stored insights and action items
+func GetSellerTimeline(ctx context.Context, query SellerQuery) (SellerTimeline, error) {
+	stored, err := LoadInsights(ctx, query.Input())
+	if err != nil {
+		return SellerTimeline{}, err
+	}
+	repo, err := insightStore.Default()
+	if err != nil {
+		return SellerTimeline{}, err
+	}
+	items, err := repo.ListActionItems(ctx, insightStore.ActionItemFilter{Glid: query.Glid})
+	if err != nil {
+		return SellerTimeline{}, err
+	}
+	return BuildSellerTimeline(query.Glid, stored, items), nil
+}
+
+// BuildSellerTimeline orders the rows of one seller by call date and derives the trajectory, the recurring
+// concerns, the next step statuses and the executives involved. A next step takes the status of its
+// action item in items, matched by insight and position.
+func BuildSellerTimeline(glid int, stored []insightStore.StoredInsight, items []insightStore.ActionItem) SellerTimeline {
+	rows := append([]insightStore.StoredInsight{}, stored...)
+	for i := range rows {
+		rows[i].CallDate = timelineDate(rows[i])
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].CallDate != rows[j].CallDate {
+			return rows[i].CallDate < rows[j].CallDate
+		}
+		return rows[i].ID < rows[j].ID
+	})
+	insights := ToInsights(rows)
+
+	timeline := SellerTimeline{
+		Glid:                glid,
+		Calls:               len(rows),
+		SentimentTrend:      sentimentShift(SentimentUnknown, SentimentUnknown),
+		SentimentTrajectory: []SentimentPoint{},
+		RecurringConcerns:   []RecurringConcern{},
+		NextSteps:           []TimelineNextStep{},
+		Executives:          []TimelineExecutive{},
+		Entries:             []TimelineEntry{},
+	}
+	if len(rows) == 0 {
+		return timeline
+	}
+	timeline.FirstCallDate = rows[0].CallDate
+	timeline.LastCallDate = rows[len(rows)-1].CallDate
+
+	concernsByCall := make([][]string, len(rows))
+	recurring := map[string]*RecurringConcern{}
+	var concernOrder []string
+	executiveIndex := map[string]int{}
+	for i, row := range rows {
+		insight := insights[i]
+		start, end := callSentiment(insight)
+		timeline.SentimentTrajectory = append(timeline.SentimentTrajectory, SentimentPoint{
+			InsightID: row.ID, CallDate: row.CallDate, Start: start, End: end, Shift: sentimentShift(start, end),
+		})
+
+		concernsByCall[i] = callConcernLabels(insight)
+		for _, concern := range concernsByCall[i] {
+			if recurring[concern] == nil {
+				recurring[concern] = &RecurringConcern{Concern: concern, FirstSeen: row.CallDate}
+				concernOrder = append(concernOrder, concern)
+			}
+			recurring[concern].Calls++
+			recurring[concern].LastSeen = row.CallDate
+		}
+
+		index, known := executiveIndex[row.ExecutiveID]
+		if !known {
+			index = len(timeline.Executives)
+			executiveIndex[row.ExecutiveID] = index
+			timeline.Executives = append(timeline.Executives, TimelineExecutive{ExecutiveID: row.ExecutiveID, FirstCallDate: row.CallDate})
+		}
+		timeline.Executives[index].Calls++
+		timeline.Executives[index].LastCallDate = row.CallDate
+
+		timeline.Entries = append(timeline.Entries, TimelineEntry{
+			InsightID:   row.ID,
+			CallDate:    row.CallDate,
+			CallType:    row.CallType,
+			ExecutiveID: row.ExecutiveID,
+			SourceURL:   row.SourceURL,
+			Concerns:    append([]string{}, concernsByCall[i]...),
+			Insight:     insight,
+			InsightV2:   insight.Structured,
+		})
+	}
+	firstStart, _ := callSentiment(insights[0])
+	_, lastEnd := callSentiment(insights[len(insights)-1])
+	timeline.SentimentTrend = sentimentShift(firstStart, lastEnd)
+
+	for _, concern := range concernOrder {
+		if recurring[concern].Calls > 1 {
+			timeline.RecurringConcerns = append(timeline.RecurringConcerns, *recurring[concern])
+		}
+	}
+
+	itemsByInsight := actionItemsByInsight(items)
+	for i, row := range rows {
+		guessed := NextStepResolved
+		if i == len(rows)-1 || concernsRecur(concernsByCall[i], concernsByCall[i+1:]) {
+			guessed = NextStepOpen
+		}
+		for position, step := range callNextSteps(insights[i]) {
+			step.InsightID, step.CallDate, step.Status = row.ID, row.CallDate, guessed
+			if position < len(itemsByInsight[row.ID]) {
+				item := itemsByInsight[row.ID][position]
+				step.ActionItemID, step.Status = item.ID, NextStepOpen
+				if item.Status == insightStore.ActionItemDone {
+					step.Status = NextStepResolved
+				}
+			}
+			timeline.NextSteps = append(timeline.NextSteps, step)
+			if step.Status == NextStepOpen {
+				timeline.OpenNextSteps++
+			} else {
+				timeline.ResolvedNextSteps++
+			}
+		}
+	}
+	return timeline
+}
+
+// actionItemsByInsight groups items by insight in the order they were extracted, which is the order
+// of the call's next steps
+func actionItemsByInsight(items []insightStore.ActionItem) map[int64][]insightStore.ActionItem {
+	sorted := append([]insightStore.ActionItem{}, items...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	byInsight := map[int64][]insightStore.ActionItem{}
+	for _, item := range sorted {
+		byInsight[item.InsightID] = append(byInsight[item.InsightID], item)
+	}
+	return byInsight
+}
+
+// timelineDate is the call date of row, or the date it was stored when the call date was unknown
+func timelineDate(row insightStore.StoredInsight) string {
+	if row.CallDate != "" || row.CreatedAt.IsZero() {
+		return row.CallDate
+	}
+	return row.CreatedAt.UTC().Format("2006-01-02")
+}
+
+// concernsRecur reports whether any of concerns is raised again on one of the later calls
+func concernsRecur(concerns []string, later [][]string) bool {
+	for _, laterConcerns := range later {
+		for _, concern := range concerns {
+			if contains(laterConcerns, concern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// callNextSteps returns the next steps of a call, typed when present and split on ";" otherwise
+func callNextSteps(insight Insights) []TimelineNextStep {
+	var steps []TimelineNextStep
+	if insight.Structured != nil {
+		for _, step := range insight.Structured.NextSteps {
+			steps = append(steps, TimelineNextStep{Owner: step.Owner, Action: step.Action, Due: step.Due})
+		}
+		return steps
+	}
+	for _, action := range strings.Split(insight.NextSteps, ";") {
+		if action = strings.TrimSpace(action); action != "" {
+			steps = append(steps, TimelineNextStep{Action: action})
+		}
+	}
+	return steps
+}
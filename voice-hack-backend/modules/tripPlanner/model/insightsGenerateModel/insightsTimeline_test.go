@@ -0,0 +1,54 @@
+package insightsGenerateModel
+
+import (
+	"testing"
+	"voice-hack-backend/utilities/insightStore"
+)
+
+func TestBuildSellerTimelineUsesActionItemStatus(t *testing.T) {
+	rows := []insightStore.StoredInsight{
+		{ID: 1, Glid: 42, CallDate: "2026-10-01", InsightType: "final", NextSteps: "Share the catalogue; Call back tomorrow"},
+		{ID: 2, Glid: 42, CallDate: "2026-10-05", InsightType: "final", NextSteps: "Send the invoice"},
+		{ID: 3, Glid: 42, CallDate: "2026-10-09", InsightType: "final", NextSteps: "Check the leads"},
+	}
+	items := []insightStore.ActionItem{
+		{ID: 11, InsightID: 1, Description: "Share the catalogue", Status: insightStore.ActionItemOpen},
+		{ID: 12, InsightID: 1, Description: "Call back tomorrow", Status: insightStore.ActionItemDone},
+		{ID: 13, InsightID: 3, Description: "Check the leads, edited", Status: insightStore.ActionItemDone},
+	}
+
+	timeline := BuildSellerTimeline(42, rows, items)
+	want := []struct {
+		action       string
+		status       string
+		actionItemID int64
+	}{
+		{"Share the catalogue", NextStepOpen, 11},
+		{"Call back tomorrow", NextStepResolved, 12},
+		{"Send the invoice", NextStepResolved, 0}, // no item, a later call happened without the concern
+		{"Check the leads", NextStepResolved, 13},
+	}
+	if len(timeline.NextSteps) != len(want) {
+		t.Fatalf("next steps = %+v", timeline.NextSteps)
+	}
+	for i, step := range timeline.NextSteps {
+		if step.Action != want[i].action || step.Status != want[i].status || step.ActionItemID != want[i].actionItemID {
+			t.Errorf("next step %d = %+v, want %+v", i, step, want[i])
+		}
+	}
+	if timeline.OpenNextSteps != 1 || timeline.ResolvedNextSteps != 3 {
+		t.Errorf("open/resolved = %d/%d, want 1/3", timeline.OpenNextSteps, timeline.ResolvedNextSteps)
+	}
+}
+
+func TestBuildSellerTimelineGuessesWithoutActionItems(t *testing.T) {
+	rows := []insightStore.StoredInsight{
+		{ID: 1, Glid: 42, CallDate: "2026-10-01", InsightType: "final", NextSteps: "Share the catalogue"},
+		{ID: 2, Glid: 42, CallDate: "2026-10-05", InsightType: "final", NextSteps: "Send the invoice"},
+	}
+
+	timeline := BuildSellerTimeline(42, rows, nil)
+	if len(timeline.NextSteps) != 2 || timeline.NextSteps[0].Status != NextStepResolved || timeline.NextSteps[1].Status != NextStepOpen {
+		t.Errorf("next steps = %+v, want the earlier call resolved and the latest open", timeline.NextSteps)
+	}
+}
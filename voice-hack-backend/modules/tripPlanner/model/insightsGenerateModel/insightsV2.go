@@ -0,0 +1,345 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	llm "voice-hack-backend/utilities/llmService"
+	prompts "voice-hack-backend/utilities/promptTemplates"
+	"voice-hack-backend/utilities/transcript"
+)
+
+// Response schema versions selectable with ApiInputParams.SchemaVersion
+const (
+	SchemaV1 = "v1" // free-text Insights (default)
+	SchemaV2 = "v2" // typed InsightsV2, flattened into Insights as well
+)
+
+// Alert types and severities of InsightsV2
+const (
+	AlertTypeChurnRisk             = "churn_risk"
+	AlertTypeUpsell                = "upsell"
+	AlertTypeExecutiveInefficiency = "executive_inefficiency"
+	AlertTypeProcessFailure        = "process_failure"
+)
+
+var alertTypeLabels = map[string]string{
+	AlertTypeChurnRisk:             AlertChurnRisk,
+	AlertTypeUpsell:                AlertUpsellOpportunity,
+	AlertTypeExecutiveInefficiency: AlertExecutiveInefficiency,
+	AlertTypeProcessFailure:        AlertProcessFailure,
+}
+
+var (
+	sentimentValues     = []string{SentimentPositive, SentimentNeutral, SentimentNegative, SentimentAngry}
+	alertTypeValues     = []string{AlertTypeChurnRisk, AlertTypeUpsell, AlertTypeExecutiveInefficiency, AlertTypeProcessFailure}
+	alertSeverityValues = []string{"low", "medium", "high"}
+	ownerPersonaValues  = []string{"executive", "seller", "manager", "sales_head", "product_team", "category_team", "payments_team", "vp"}
+)
+
+type ConcernV2 struct {
+	Category    string `json:"category"` // ConcernCodes code
+	Description string `json:"description"`
+}
+
+type SentimentV2 struct {
+	Start string `json:"start"` // Positive, Neutral, Negative or Angry
+	End   string `json:"end"`
+}
+
+type AlertV2 struct {
+	Type        string `json:"type"`     // churn_risk, upsell, executive_inefficiency or process_failure
+	Severity    string `json:"severity"` // low, medium or high
+	Description string `json:"description"`
+}
+
+type NextStepV2 struct {
+	Owner  string `json:"owner"` // persona responsible, e.g. executive or seller
+	Action string `json:"action"`
+	Due    string `json:"due"` // e.g. "tomorrow", "within 2 days" or a date
+}
+
+type InsightsV2 struct {
+	InsightType string       `json:"insight_type"` // call_1, call_2 ... or final
+	Concerns    []ConcernV2  `json:"concerns"`
+	Resolution  string       `json:"resolution"`
+	NextSteps   []NextStepV2 `json:"next_steps"`
+	Alerts      []AlertV2    `json:"alerts"` // empty when nothing needs flagging
+	Sentiment   SentimentV2  `json:"sentiment"`
+	KeyPoints   []string     `json:"key_points"`
+
+	Metrics  *transcript.Metrics `json:"metrics,omitempty"`  // Measured by the backend, never requested from the LLM
+	Language string              `json:"language,omitempty"` // Detected by the backend, call-level blocks only
+
+	Compliance *ComplianceResult `json:"compliance,omitempty"` // Checked against the knowledge base, call-level blocks only
+}
+
+type ContentGenerationResponseV2 struct {
+	Insights []InsightsV2 `json:"insights"`
+}
+
+// GenerateInsightsV2FromLLM asks the provider for typed insights, re-prompting until the enums and blocks are valid
+func GenerateInsightsV2FromLLM(ctx context.Context, userQuery string, input ApiInputParams) (result ContentGenerationResponseV2, model string, err error) {
+	provider, err := llm.GetProvider(input.LLMProvider)
+	if err != nil {
+		return
+	}
+	input.SchemaVersion = SchemaV2
+	systemQuery, err := GetSystemQuery(input)
+	if err != nil {
+		return
+	}
+
+	response, err := CompleteWithRepair(ctx, provider, llm.CompletionRequest{
+		SystemPrompt: systemQuery,
+		UserPrompt:   userQuery,
+		JSONMode:     true,
+		Schema:       InsightsV2ResponseSchema(),
+		SchemaName:   "insights_v2",
+	}, input, func(content string) []string {
+		result = ContentGenerationResponseV2{}
+		if problems := decodeJSON(content, &result); problems != nil {
+			return problems
+		}
+		types := make([]string, 0, len(result.Insights))
+		for _, insight := range result.Insights {
+			types = append(types, insight.InsightType)
+		}
+		return append(result.Validate(), validateInsightTypes("insights", "insight_type", types, len(input.CallData))...)
+	})
+	model = response.Model
+	return
+}
+
+// GenerateVersionedInsights generates insights in the schema chosen by input.SchemaVersion.
+// v2 insights are also flattened into Locations so v1 consumers keep working.
+func GenerateVersionedInsights(ctx context.Context, userQuery string, input ApiInputParams) (ContentGenerationResponse, error) {
+	if input.SchemaVersion != SchemaV2 {
+		return GenerateInsightsFromLLM(ctx, userQuery, input)
+	}
+
+	structured, model, err := GenerateInsightsV2FromLLM(ctx, userQuery, input)
+	if err != nil {
+		return ContentGenerationResponse{}, err
+	}
+	resp := ContentGenerationResponse{
+		Model:              model,
+		PromptVersion:      prompts.Resolve(input.PromptVersion),
+		StructuredInsights: structured.Insights,
+	}
+	for _, insight := range structured.Insights {
+		resp.Locations = append(resp.Locations, insight.ToV1())
+	}
+	return resp, nil
+}
+
+// Validate checks every enum and required field, returning one message per problem
+func (resp ContentGenerationResponseV2) Validate() []string {
+	var problems []string
+	if len(resp.Insights) == 0 {
+		problems = append(problems, "insights: at least one block is required")
+	}
+	for i, insight := range resp.Insights {
+		prefix := fmt.Sprintf("insights[%d]", i)
+		if insight.InsightType == "" {
+			problems = append(problems, prefix+".insight_type: required")
+		}
+		for j, concern := range insight.Concerns {
+			if !isConcernCode(concern.Category) {
+				problems = append(problems, fmt.Sprintf("%s.concerns[%d].category: %q is not a known concern category", prefix, j, concern.Category))
+			}
+		}
+		if !contains(sentimentValues, insight.Sentiment.Start) {
+			problems = append(problems, fmt.Sprintf("%s.sentiment.start: %q must be one of %s", prefix, insight.Sentiment.Start, strings.Join(sentimentValues, ", ")))
+		}
+		if !contains(sentimentValues, insight.Sentiment.End) {
+			problems = append(problems, fmt.Sprintf("%s.sentiment.end: %q must be one of %s", prefix, insight.Sentiment.End, strings.Join(sentimentValues, ", ")))
+		}
+		for j, alert := range insight.Alerts {
+			if !contains(alertTypeValues, alert.Type) {
+				problems = append(problems, fmt.Sprintf("%s.alerts[%d].type: %q must be one of %s", prefix, j, alert.Type, strings.Join(alertTypeValues, ", ")))
+			}
+			if !contains(alertSeverityValues, alert.Severity) {
+				problems = append(problems, fmt.Sprintf("%s.alerts[%d].severity: %q must be one of %s", prefix, j, alert.Severity, strings.Join(alertSeverityValues, ", ")))
+			}
+		}
+		for j, step := range insight.NextSteps {
+			if !contains(ownerPersonaValues, step.Owner) {
+				problems = append(problems, fmt.Sprintf("%s.next_steps[%d].owner: %q must be one of %s", prefix, j, step.Owner, strings.Join(ownerPersonaValues, ", ")))
+			}
+			if strings.TrimSpace(step.Action) == "" {
+				problems = append(problems, fmt.Sprintf("%s.next_steps[%d].action: required", prefix, j))
+			}
+		}
+	}
+	return problems
+}
+
+// ToV1 flattens a typed insight into the free-text v1 shape, keeping a pointer to the typed one
+func (insight InsightsV2) ToV1() Insights {
+	concerns := make([]string, 0, len(insight.Concerns))
+	for _, concern := range insight.Concerns {
+		concerns = append(concerns, ConcernLabel(concern.Category)+": "+concern.Description)
+	}
+	nextSteps := make([]string, 0, len(insight.NextSteps))
+	for _, step := range insight.NextSteps {
+		text := personaLabel(step.Owner) + ": " + step.Action
+		if step.Due != "" {
+			text += " (due " + step.Due + ")"
+		}
+		nextSteps = append(nextSteps, text)
+	}
+	alerts := make([]string, 0, len(insight.Alerts))
+	for _, alert := range insight.Alerts {
+		alerts = append(alerts, fmt.Sprintf("%s (%s): %s", alertTypeLabels[alert.Type], alert.Severity, alert.Description))
+	}
+	alert := AlertNone
+	if len(alerts) > 0 {
+		alert = strings.Join(alerts, "; ")
+	}
+
+	structured := insight
+	return Insights{
+		InsightType: insight.InsightType,
+		Concerns:    strings.Join(concerns, "; "),
+		Resolution:  insight.Resolution,
+		NextSteps:   strings.Join(nextSteps, "; "),
+		Alert:       alert,
+		Sentiment:   insight.Sentiment.Start + " -> " + insight.Sentiment.End,
+		KeyPoints:   strings.Join(insight.KeyPoints, ", "),
+		Structured:  &structured,
+	}
+}
+
+// ConcernLabel returns the display label of a concern category code
+func ConcernLabel(code string) string {
+	for _, category := range ConcernCategories() {
+		if category.Code == code {
+			return category.Label
+		}
+	}
+	return code
+}
+
+// ConcernCodes lists the valid concern category codes, the knowledge base ones followed by other
+func ConcernCodes() []string {
+	categories := ConcernCategories()
+	codes := make([]string, 0, len(categories))
+	for _, category := range categories {
+		codes = append(codes, category.Code)
+	}
+	return codes
+}
+
+func isConcernCode(code string) bool {
+	return contains(ConcernCodes(), code)
+}
+
+func personaLabel(owner string) string {
+	words := strings.Split(owner, "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// InsightsV2ResponseSchema describes ContentGenerationResponseV2 for structured output
+func InsightsV2ResponseSchema() *llm.Schema {
+	stringField := &llm.Schema{Type: "string"}
+	return &llm.Schema{
+		Type: "object",
+		Properties: map[string]*llm.Schema{
+			"insights": {
+				Type: "array",
+				Items: &llm.Schema{
+					Type: "object",
+					Properties: map[string]*llm.Schema{
+						"insight_type": stringField,
+						"concerns": {
+							Type: "array",
+							Items: &llm.Schema{
+								Type: "object",
+								Properties: map[string]*llm.Schema{
+									"category":    {Type: "string", Enum: ConcernCodes()},
+									"description": stringField,
+								},
+								Required: []string{"category", "description"},
+							},
+						},
+						"resolution": stringField,
+						"next_steps": {
+							Type: "array",
+							Items: &llm.Schema{
+								Type: "object",
+								Properties: map[string]*llm.Schema{
+									"owner":  {Type: "string", Enum: ownerPersonaValues},
+									"action": stringField,
+									"due":    stringField,
+								},
+								Required: []string{"owner", "action", "due"},
+							},
+						},
+						"alerts": {
+							Type: "array",
+							Items: &llm.Schema{
+								Type: "object",
+								Properties: map[string]*llm.Schema{
+									"type":        {Type: "string", Enum: alertTypeValues},
+									"severity":    {Type: "string", Enum: alertSeverityValues},
+									"description": stringField,
+								},
+								Required: []string{"type", "severity", "description"},
+							},
+						},
+						"sentiment": {
+							Type: "object",
+							Properties: map[string]*llm.Schema{
+								"start": {Type: "string", Enum: sentimentValues},
+								"end":   {Type: "string", Enum: sentimentValues},
+							},
+							Required: []string{"start", "end"},
+						},
+						"key_points": {Type: "array", Items: stringField},
+					},
+					Required: []string{"insight_type", "concerns", "resolution", "next_steps", "alerts", "sentiment", "key_points"},
+				},
+			},
+		},
+		Required: []string{"insights"},
+	}
+}
+
+// insightsV2Example is the JSON example shown in the v2 system prompt
+func insightsV2Example() ContentGenerationResponseV2 {
+	return ContentGenerationResponseV2{
+		Insights: []InsightsV2{
+			{
+				InsightType: "final",
+				Concerns: []ConcernV2{
+					{Category: "irrelevant_buyleads", Description: "Leads from locations the seller does not serve"},
+				},
+				Resolution: "Checked location settings and advised bulk filters",
+				NextSteps: []NextStepV2{
+					{Owner: "executive", Action: "Call back to verify lead quality", Due: "tomorrow"},
+					{Owner: "seller", Action: "Consume leads from preferred locations", Due: "within 2 days"},
+				},
+				Alerts: []AlertV2{
+					{Type: AlertTypeUpsell, Severity: "medium", Description: "Seller wants high quantity leads"},
+				},
+				Sentiment: SentimentV2{Start: SentimentNegative, End: SentimentNeutral},
+				KeyPoints: []string{"Buy leads", "Location Filter", "Upsell"},
+			},
+		},
+	}
+}
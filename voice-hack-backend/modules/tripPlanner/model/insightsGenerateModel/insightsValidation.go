@@ -0,0 +1,160 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/globalFunctions"
+	llm "voice-hack-backend/utilities/llmService"
+)
+
+// LLMOutputError is returned when the model reply is still invalid after every repair attempt
+type LLMOutputError struct {
+	Attempts int      // completions requested, the first one included
+	Problems []string // validation problems of the last reply
+	Raw      string   // last raw reply
+}
+
+func (e *LLMOutputError) Error() string {
+	return fmt.Sprintf("LLM returned invalid JSON after %d attempt(s): %s", e.Attempts, strings.Join(e.Problems, "; "))
+}
+
+// ParseFunc decodes a raw reply, returning one message per problem (none when the reply is usable)
+type ParseFunc func(content string) []string
+
+// CompleteWithRepair sends req and validates the reply with parse. An invalid reply is sent back to the
+// model together with the problems, up to llm.repair_attempts times, before an *LLMOutputError is returned.
+func CompleteWithRepair(ctx context.Context, provider llm.Provider, req llm.CompletionRequest, input ApiInputParams, parse ParseFunc) (llm.CompletionResponse, error) {
+	maxAttempts := config.Get().LLM.RepairAttempts + 1
+	for attempt := 1; ; attempt++ {
+		response, err := provider.Complete(ctx, req)
+		if err != nil {
+			return response, fmt.Errorf("LLM service call failed: %v", err)
+		}
+
+		problems := parse(response.Content)
+		if len(problems) == 0 {
+			if attempt > 1 {
+				logRepairAttempt(input, provider.Name(), attempt, "repaired", nil, "")
+			}
+			return response, nil
+		}
+
+		if attempt >= maxAttempts {
+			logRepairAttempt(input, provider.Name(), attempt, "failed", problems, response.Content)
+			return response, &LLMOutputError{Attempts: attempt, Problems: problems, Raw: response.Content}
+		}
+		logRepairAttempt(input, provider.Name(), attempt, "retrying", problems, response.Content)
+		req.Messages = append(req.Messages,
+			llm.Message{Role: "assistant", Content: response.Content},
+			llm.Message{Role: "user", Content: repairPrompt(problems)},
+		)
+	}
+}
+
+func repairPrompt(problems []string) string {
+	var b strings.Builder
+	b.WriteString("Your previous response was rejected because of these problems:\n")
+	for _, problem := range problems {
+		b.WriteString("- " + problem + "\n")
+	}
+	b.WriteString("Return the complete corrected response as valid JSON only, following the required structure. Do not add any text outside the JSON.")
+	return b.String()
+}
+
+func logRepairAttempt(input ApiInputParams, provider string, attempt int, outcome string, problems []string, raw string) {
+	globalFunctions.WriteJsonLogs(nil, "llm_repair", map[string]any{
+		"glid":         input.Glid,
+		"executive_id": input.ExecutiveID,
+		"provider":     provider,
+		"attempt":      attempt,
+		"outcome":      outcome,
+		"problems":     problems,
+		"raw_response": raw,
+	})
+}
+
+// decodeJSON extracts the JSON object from a reply and unmarshals it into target
+func decodeJSON(content string, target any) []string {
+	rawJSON, err := globalFunctions.ExtractJson(content)
+	if err != nil {
+		return []string{fmt.Sprintf("response is not valid JSON: %v", err)}
+	}
+	if err := json.Unmarshal([]byte(rawJSON), target); err != nil {
+		return []string{fmt.Sprintf("response does not match the expected structure: %v", err)}
+	}
+	return nil
+}
+
+// ValidateInsightsResponse checks a v1 response: required fields of every block and
+// InsightType values matching the number of calls (call_1..call_N for several calls, one final block)
+func ValidateInsightsResponse(resp ContentGenerationResponse, callCount int) []string {
+	if len(resp.Locations) == 0 {
+		return []string{"Insights: at least one block is required"}
+	}
+	var problems []string
+	types := make([]string, 0, len(resp.Locations))
+	for i, insight := range resp.Locations {
+		problems = append(problems, validateInsightFields(fmt.Sprintf("Insights[%d]", i), insight)...)
+		types = append(types, insight.InsightType)
+	}
+	return append(problems, validateInsightTypes("Insights", "InsightType", types, callCount)...)
+}
+
+// validateInsightFields reports the empty required fields of a v1 block
+func validateInsightFields(prefix string, insight Insights) []string {
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"InsightType", insight.InsightType},
+		{"Concerns", insight.Concerns},
+		{"Resolution", insight.Resolution},
+		{"NextSteps", insight.NextSteps},
+		{"Alert", insight.Alert},
+		{"Sentiment", insight.Sentiment},
+		{"KeyPoints", insight.KeyPoints},
+	}
+	var problems []string
+	for _, field := range fields {
+		if strings.TrimSpace(field.value) == "" {
+			problems = append(problems, fmt.Sprintf("%s.%s: required", prefix, field.name))
+		}
+	}
+	return problems
+}
+
+// validateInsightTypes expects exactly one final block and, for several calls, exactly one call_i block per call
+func validateInsightTypes(list string, field string, types []string, callCount int) []string {
+	var problems []string
+	seen := map[string]int{}
+	for i, insightType := range types {
+		seen[insightType]++
+		if insightType != "final" && CallIndexOf(insightType, callCount) == 0 {
+			problems = append(problems, fmt.Sprintf("%s[%d].%s: %q is not valid for %d call(s)", list, i, field, insightType, callCount))
+		} else if insightType != "final" && callCount == 1 {
+			problems = append(problems, fmt.Sprintf("%s[%d].%s: a single call only gets a final block, not %q", list, i, field, insightType))
+		}
+	}
+
+	switch seen["final"] {
+	case 0:
+		problems = append(problems, fmt.Sprintf("%s: the final block is missing", list))
+	case 1:
+	default:
+		problems = append(problems, fmt.Sprintf("%s: expected one final block, got %d", list, seen["final"]))
+	}
+	if callCount > 1 {
+		for index := 1; index <= callCount; index++ {
+			callType := fmt.Sprintf("call_%d", index)
+			if seen[callType] == 0 {
+				problems = append(problems, fmt.Sprintf("%s: the %s block is missing", list, callType))
+			} else if seen[callType] > 1 {
+				problems = append(problems, fmt.Sprintf("%s: expected one %s block, got %d", list, callType, seen[callType]))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,97 @@
+package insightsGenerateModel
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"voice-hack-backend/config"
+	llm "voice-hack-backend/utilities/llmService"
+)
+
+const validFinalReply = `{"Insights":[{"InsightType":"final","Concerns":"Lead quality","Resolution":"Explained filters","NextSteps":"Call back on Monday","Alert":"None","Sentiment":"Neutral","KeyPoints":"Asked about leads"}]}`
+
+// useFakeProvider registers a fresh fake provider and sets llm.repair_attempts
+func useFakeProvider(t *testing.T, repairAttempts int) *llm.FakeProvider {
+	t.Helper()
+	cfg := config.Defaults()
+	cfg.LLM.RepairAttempts = repairAttempts
+	previous := config.Get()
+	config.Set(&cfg)
+	t.Cleanup(func() { config.Set(previous) })
+
+	fake := llm.NewFakeProvider()
+	llm.RegisterProvider(fake)
+	return fake
+}
+
+// singleCallInput is a one-call request whose samples are already set, so no vector DB is queried
+func singleCallInput() ApiInputParams {
+	return ApiInputParams{
+		Glid:               42,
+		LLMProvider:        llm.ProviderFake,
+		SampleCalls:        "no samples",
+		CallData:           []CallData{{CallType: "PNS"}},
+		TrascriptionURLTxt: []string{"Seller: the leads are not relevant"},
+	}
+}
+
+func TestGenerateInsightsFromLLMParsesReply(t *testing.T) {
+	fake := useFakeProvider(t, 0)
+	fake.Push(validFinalReply)
+
+	result, err := GenerateInsightsFromLLM(context.Background(), "user query", singleCallInput())
+	if err != nil {
+		t.Fatalf("GenerateInsightsFromLLM: %v", err)
+	}
+	if len(result.Locations) != 1 || result.Locations[0].Concerns != "Lead quality" || result.Model != "fake" {
+		t.Errorf("result = %+v", result)
+	}
+	requests := fake.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(requests))
+	}
+	if requests[0].UserPrompt != "user query" || requests[0].SystemPrompt == "" || requests[0].Schema == nil {
+		t.Errorf("request = %+v, want the user query, a system prompt and the schema", requests[0])
+	}
+}
+
+func TestGenerateInsightsFromLLMRepairsInvalidReply(t *testing.T) {
+	fake := useFakeProvider(t, 1)
+	fake.Push(`{"Insights":[{"InsightType":"call_1","Concerns":"Lead quality"}]}`, validFinalReply)
+
+	result, err := GenerateInsightsFromLLM(context.Background(), "user query", singleCallInput())
+	if err != nil {
+		t.Fatalf("GenerateInsightsFromLLM: %v", err)
+	}
+	if len(result.Locations) != 1 || result.Locations[0].InsightType != "final" {
+		t.Errorf("result = %+v, want the repaired reply", result)
+	}
+	requests := fake.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("sent %d requests, want the first attempt and one repair", len(requests))
+	}
+	repair := requests[1].Messages
+	if len(repair) != 2 || repair[0].Role != "assistant" || repair[1].Role != "user" {
+		t.Fatalf("repair turns = %+v, want the rejected reply and the problems", repair)
+	}
+	for _, want := range []string{"Insights[0].Resolution: required", "the final block is missing"} {
+		if !strings.Contains(repair[1].Content, want) {
+			t.Errorf("repair prompt misses %q:\n%s", want, repair[1].Content)
+		}
+	}
+}
+
+func TestGenerateInsightsFromLLMGivesUpAfterRepairAttempts(t *testing.T) {
+	fake := useFakeProvider(t, 1)
+	fake.Push("not json", "still not json")
+
+	_, err := GenerateInsightsFromLLM(context.Background(), "user query", singleCallInput())
+	var outputErr *LLMOutputError
+	if !errors.As(err, &outputErr) {
+		t.Fatalf("error = %v, want an LLMOutputError", err)
+	}
+	if outputErr.Attempts != 2 || outputErr.Raw != "still not json" {
+		t.Errorf("LLMOutputError = %+v", outputErr)
+	}
+}
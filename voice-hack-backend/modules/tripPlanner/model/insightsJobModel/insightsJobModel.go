@@ -0,0 +1,438 @@
+package insightsJobModel
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"voice-hack-backend/config"
+	insightsGenerateModel "voice-hack-backend/modules/tripPlanner/model/insightsGenerateModel"
+	urlMedia "voice-hack-backend/utilities/urlMedia"
+)
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrQueueFull   = errors.New("job queue is full, retry later")
+)
+
+type JobProgress struct {
+	Stage   string `json:"stage"`
+	Done    int    `json:"done,omitempty"`
+	Total   int    `json:"total,omitempty"`
+	Message string `json:"message"` // e.g. "transcribing call 2/5"
+}
+
+type Job struct {
+	ID              string                                           `json:"id"`
+	Status          JobStatus                                        `json:"status"`
+	Progress        JobProgress                                      `json:"progress"`
+	Input           insightsGenerateModel.ApiInputParams             `json:"-"` // holds the transcripts, only kept in the job file
+	Result          *insightsGenerateModel.ContentGenerationResponse `json:"result,omitempty"`
+	Error           string                                           `json:"error,omitempty"`
+	Retries         int                                              `json:"retries,omitempty"`           // re-runs while a transcription was still processing
+	PendingMediaIDs map[int]string                                   `json:"pending_media_ids,omitempty"` // media service transcriptions by call index, polled again on retry
+	Uploads         map[int]string                                   `json:"uploads,omitempty"`           // uploaded file name by call index, the audio is kept next to the job file
+	CreatedAt       time.Time                                        `json:"created_at"`
+	UpdatedAt       time.Time                                        `json:"updated_at"`
+}
+
+type JobApiResponse struct {
+	insightsGenerateModel.ApiStatus
+	Job *Job `json:"job,omitempty"`
+}
+
+// storedJob is the job file, the only place Job.Input is written to
+type storedJob struct {
+	*Job
+	Input insightsGenerateModel.ApiInputParams `json:"input"`
+}
+
+// cleanupInterval is how often finished jobs are checked against jobs.ttl
+const cleanupInterval = time.Minute
+
+var (
+	jobsMu   sync.Mutex
+	jobs     = map[string]*Job{}
+	jobQueue chan string
+	jobsDir  string
+	jobsCfg  config.JobsConfig
+)
+
+// Start loads persisted jobs, re-queues the unfinished ones and starts the worker pool.
+// Finished jobs are deleted once they are older than cfg.TTL.
+func Start(cfg config.JobsConfig) error {
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create jobs dir: %w", err)
+	}
+	jobsDir = cfg.Dir
+	jobsCfg = cfg
+	jobQueue = make(chan string, cfg.QueueSize)
+
+	pending, loadErr := loadJobs()
+	if loadErr != nil {
+		return loadErr
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		go worker()
+	}
+	if cfg.TTL > 0 {
+		go func() {
+			for {
+				removeExpiredJobs(time.Now().Add(-cfg.TTL))
+				time.Sleep(cleanupInterval)
+			}
+		}()
+	}
+	// Re-queue in the background so a backlog larger than the queue does not block startup
+	go func() {
+		for _, id := range pending {
+			jobQueue <- id
+		}
+	}()
+	return nil
+}
+
+// Submit persists a new queued job and hands it to the worker pool
+func Submit(input insightsGenerateModel.ApiInputParams) (*Job, error) {
+	if jobQueue == nil {
+		return nil, errors.New("job workers are not running")
+	}
+	now := time.Now()
+	job := &Job{
+		ID:        newJobID(),
+		Status:    JobQueued,
+		Progress:  JobProgress{Stage: string(JobQueued), Message: "waiting for a worker"},
+		Input:     input,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	jobsMu.Lock()
+	jobs[job.ID] = job
+	saveErr := saveUploads(job)
+	if saveErr == nil {
+		saveErr = saveJob(job)
+	}
+	snapshot := snapshotOf(job)
+	jobsMu.Unlock()
+	if saveErr != nil {
+		forgetJob(job.ID)
+		return nil, saveErr
+	}
+
+	select {
+	case jobQueue <- job.ID:
+		return snapshot, nil
+	default:
+		forgetJob(job.ID)
+		return nil, ErrQueueFull
+	}
+}
+
+// Get returns a copy of the job with the given ID
+func Get(id string) (*Job, error) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	job, ok := jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return snapshotOf(job), nil
+}
+
+// snapshotOf copies job with its own maps and result slices, so it can be read after jobsMu is
+// released while a worker keeps updating the job. Callers hold jobsMu.
+func snapshotOf(job *Job) *Job {
+	snapshot := *job
+	snapshot.PendingMediaIDs = copyCallMap(job.PendingMediaIDs)
+	snapshot.Uploads = copyCallMap(job.Uploads)
+	if job.Result != nil {
+		result := *job.Result
+		result.Locations = copySlice(job.Result.Locations)
+		result.InsightIDs = copySlice(job.Result.InsightIDs)
+		result.StructuredInsights = copySlice(job.Result.StructuredInsights)
+		result.PriorCallsUsed = copySlice(job.Result.PriorCallsUsed)
+		snapshot.Result = &result
+	}
+	return &snapshot
+}
+
+// copySlice keeps a nil slice nil so the JSON of the copy matches the job
+func copySlice[T any](values []T) []T {
+	if values == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(values)), values...)
+}
+
+func copyCallMap(values map[int]string) map[int]string {
+	if values == nil {
+		return nil
+	}
+	copied := make(map[int]string, len(values))
+	for index, value := range values {
+		copied[index] = value
+	}
+	return copied
+}
+
+func worker() {
+	for id := range jobQueue {
+		runJob(id)
+	}
+}
+
+func runJob(id string) {
+	jobsMu.Lock()
+	job, ok := jobs[id]
+	if !ok {
+		jobsMu.Unlock()
+		return
+	}
+	input := job.Input
+	input.PendingMediaIDs = copyCallMap(job.PendingMediaIDs)
+	uploads := copyCallMap(job.Uploads)
+	retries := job.Retries
+	jobsMu.Unlock()
+
+	update(id, func(job *Job) {
+		job.Status = JobRunning
+	})
+	input, err := loadUploads(id, input, uploads)
+	pipelineInput, resp := input, insightsGenerateModel.ContentGenerationResponse{}
+	if err == nil {
+		pipelineInput, resp, err = insightsGenerateModel.RunInsightsPipeline(context.Background(), input, func(stage string, done int, total int) {
+			update(id, func(job *Job) {
+				job.Progress = progressFor(stage, done, total)
+			})
+		})
+	}
+
+	// A transcription that is still processing is retried later instead of failing the job,
+	// polling the transcriptions already submitted rather than sending the calls again
+	if errors.Is(err, urlMedia.ErrTranscriptionProcessing) && retries < jobsCfg.MaxRetries {
+		var pending *insightsGenerateModel.PendingTranscriptionError
+		errors.As(err, &pending)
+		update(id, func(job *Job) {
+			if pending != nil {
+				if job.PendingMediaIDs == nil {
+					job.PendingMediaIDs = map[int]string{}
+				}
+				for index, mediaID := range pending.MediaIDs {
+					job.PendingMediaIDs[index] = mediaID
+				}
+			}
+			job.Status = JobQueued
+			job.Retries++
+			job.Error = err.Error()
+			job.Progress = JobProgress{
+				Stage:   string(JobQueued),
+				Message: fmt.Sprintf("transcription still processing, retry %d/%d in %s", job.Retries, jobsCfg.MaxRetries, jobsCfg.RetryDelay),
+			}
+		})
+		time.AfterFunc(jobsCfg.RetryDelay, func() { jobQueue <- id })
+		return
+	}
+
+	apiResponse := insightsGenerateModel.ApiResponse{Code: http.StatusOK, Status: "Success", Response: resp}
+	update(id, func(job *Job) {
+		if err != nil {
+			job.Status = JobFailed
+			job.Error = err.Error()
+			apiResponse = insightsGenerateModel.ApiResponse{Code: http.StatusInternalServerError, Status: "Failure", Error: err.Error()}
+			return
+		}
+		job.Status = JobCompleted
+		job.Error = ""
+		job.Result = &resp
+		job.Progress = JobProgress{Stage: string(JobCompleted), Message: "completed"}
+		if resp.StoreError != "" {
+			job.Error = "insights not stored: " + resp.StoreError
+			job.Progress.Message = "completed, insights not stored"
+		}
+	})
+	removeUploads(id)
+	insightsGenerateModel.CreateApplicationLogs(nil, pipelineInput, apiResponse)
+}
+
+func progressFor(stage string, done int, total int) JobProgress {
+	progress := JobProgress{Stage: stage, Done: done, Total: total}
+	switch stage {
+	case insightsGenerateModel.StageTranscribing:
+		current := done + 1
+		if current > total {
+			current = total
+		}
+		progress.Message = fmt.Sprintf("transcribing call %d/%d", current, total)
+	case insightsGenerateModel.StageRetrieving:
+		progress.Message = "retrieving samples"
+	case insightsGenerateModel.StageGenerating:
+		progress.Message = "generating"
+	default:
+		progress.Message = strings.ReplaceAll(stage, "_", " ")
+	}
+	return progress
+}
+
+// update applies fn to the job and persists it
+func update(id string, fn func(job *Job)) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	job, ok := jobs[id]
+	if !ok {
+		return
+	}
+	fn(job)
+	job.UpdatedAt = time.Now()
+	if err := saveJob(job); err != nil {
+		fmt.Println("failed to persist job", id, err)
+	}
+}
+
+func forgetJob(id string) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	delete(jobs, id)
+	os.Remove(jobPath(id))
+	removeUploads(id)
+}
+
+// removeExpiredJobs deletes the completed and failed jobs last updated before cutoff
+func removeExpiredJobs(cutoff time.Time) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	for id, job := range jobs {
+		if (job.Status == JobCompleted || job.Status == JobFailed) && job.UpdatedAt.Before(cutoff) {
+			delete(jobs, id)
+			os.Remove(jobPath(id))
+			removeUploads(id)
+		}
+	}
+}
+
+// saveUploads writes the uploaded audio of the job's calls next to the job file and drops it from
+// the job, so queued jobs keep their audio across restarts without holding it in memory.
+// Callers hold jobsMu.
+func saveUploads(job *Job) error {
+	callData := append([]insightsGenerateModel.CallData{}, job.Input.CallData...)
+	for index := range callData {
+		if len(callData[index].Audio) == 0 {
+			continue
+		}
+		if err := os.WriteFile(uploadPath(job.ID, index), callData[index].Audio, 0600); err != nil {
+			return fmt.Errorf("error writing uploaded audio: %w", err)
+		}
+		if job.Uploads == nil {
+			job.Uploads = map[int]string{}
+		}
+		job.Uploads[index] = callData[index].AudioFileName
+		callData[index].Audio = nil
+	}
+	job.Input.CallData = callData
+	return nil
+}
+
+// loadUploads reads the audio saved by saveUploads back into a copy of the job's calls
+func loadUploads(id string, input insightsGenerateModel.ApiInputParams, uploads map[int]string) (insightsGenerateModel.ApiInputParams, error) {
+	if len(uploads) == 0 {
+		return input, nil
+	}
+	callData := append([]insightsGenerateModel.CallData{}, input.CallData...)
+	for index, fileName := range uploads {
+		if index >= len(callData) {
+			continue
+		}
+		audio, err := os.ReadFile(uploadPath(id, index))
+		if err != nil {
+			return input, fmt.Errorf("uploaded audio of call %d is missing: %w", index+1, err)
+		}
+		callData[index].Audio = audio
+		callData[index].AudioFileName = fileName
+	}
+	input.CallData = callData
+	return input, nil
+}
+
+func removeUploads(id string) {
+	files, _ := filepath.Glob(filepath.Join(jobsDir, id+".call*.audio"))
+	for _, file := range files {
+		os.Remove(file)
+	}
+}
+
+// saveJob writes the job atomically, callers hold jobsMu
+func saveJob(job *Job) error {
+	jobBytes, err := json.MarshalIndent(storedJob{Job: job, Input: job.Input}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling job: %w", err)
+	}
+	tmpPath := jobPath(job.ID) + ".tmp"
+	if err := os.WriteFile(tmpPath, jobBytes, 0600); err != nil {
+		return fmt.Errorf("error writing job file: %w", err)
+	}
+	return os.Rename(tmpPath, jobPath(job.ID))
+}
+
+// loadJobs reads every persisted job and returns the IDs that still need to run
+func loadJobs() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(jobsDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	var pending []string
+	for _, file := range files {
+		jobBytes, readErr := os.ReadFile(file)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read job file %s: %w", file, readErr)
+		}
+		var job Job
+		stored := storedJob{Job: &job}
+		if unmarshalErr := json.Unmarshal(jobBytes, &stored); unmarshalErr != nil {
+			fmt.Println("skipping unreadable job file", file, unmarshalErr)
+			continue
+		}
+		job.Input = stored.Input
+		if job.Status == JobQueued || job.Status == JobRunning {
+			// A running job was interrupted by the restart, start it over
+			job.Status = JobQueued
+			job.Progress = JobProgress{Stage: string(JobQueued), Message: "re-queued after restart"}
+			pending = append(pending, job.ID)
+		}
+		jobs[job.ID] = &job
+	}
+	return pending, nil
+}
+
+func jobPath(id string) string {
+	return filepath.Join(jobsDir, id+".json")
+}
+
+func uploadPath(id string, index int) string {
+	return filepath.Join(jobsDir, fmt.Sprintf("%s.call%d.audio", id, index+1))
+}
+
+func newJobID() string {
+	idBytes := make([]byte, 16)
+	rand.Read(idBytes)
+	return hex.EncodeToString(idBytes)
+}
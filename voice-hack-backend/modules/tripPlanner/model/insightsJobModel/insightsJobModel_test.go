@@ -0,0 +1,64 @@
+package insightsJobModel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+	"voice-hack-backend/config"
+	insightsGenerateModel "voice-hack-backend/modules/tripPlanner/model/insightsGenerateModel"
+	transcription "voice-hack-backend/utilities/transcriptionService"
+	urlMedia "voice-hack-backend/utilities/urlMedia"
+)
+
+// processingTranscriber reports every call as still processing under a media ID of its own
+type processingTranscriber struct{}
+
+func (processingTranscriber) Name() string { return "processing" }
+
+func (processingTranscriber) Transcribe(_ context.Context, req transcription.Request) (string, error) {
+	return "", &urlMedia.ProcessingError{MediaID: "media-" + req.RecordingURL, Status: "processing"}
+}
+
+func TestGetWhileRetryUpdatesPendingMediaIDs(t *testing.T) {
+	transcription.Register(processingTranscriber{})
+	transcription.SetDefault("processing")
+	t.Cleanup(func() { transcription.Configure(config.Get().Transcription) })
+
+	const maxRetries = 20
+	if err := Start(config.JobsConfig{Dir: t.TempDir(), Workers: 1, QueueSize: 4, MaxRetries: maxRetries, RetryDelay: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+
+	const calls = 8
+	input := insightsGenerateModel.ApiInputParams{Glid: 42, TranscriptionConcurrency: calls}
+	for i := 1; i <= calls; i++ {
+		input.CallData = append(input.CallData, insightsGenerateModel.CallData{CallRecordingURL: fmt.Sprintf("https://recordings.example.com/%d.mp3", i)})
+	}
+	submitted, err := Submit(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Encoding the job as the controller does races with the retries when Get shares their maps
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		job, getErr := Get(submitted.ID)
+		if getErr != nil {
+			t.Fatal(getErr)
+		}
+		if _, marshalErr := json.Marshal(job); marshalErr != nil {
+			t.Fatal(marshalErr)
+		}
+		if job.Status == JobFailed {
+			if job.Retries != maxRetries || len(job.PendingMediaIDs) != calls {
+				t.Errorf("failed after %d retries with %d pending media IDs, want %d and %d", job.Retries, len(job.PendingMediaIDs), maxRetries, calls)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job still %s after %d retries", job.Status, job.Retries)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"voice-hack-backend/config"
+)
+
+// Notifier types understood by Configure
+const (
+	TypeWebhook = "webhook" // JSON POST of the notification
+	TypeSlack   = "slack"   // Slack-compatible incoming webhook
+	TypeEmail   = "email"   // plain-text mail over SMTP
+)
+
+// Notification is one alert sent to a notifier
+type Notification struct {
+	DeliveryID   int64  `json:"delivery_id"`
+	Rule         string `json:"rule"`
+	AlertType    string `json:"alert_type"`
+	Severity     string `json:"severity"`
+	Description  string `json:"description"`
+	Glid         int    `json:"glid"`
+	ExecutiveID  string `json:"executive_id"`
+	CustomerType string `json:"customer_type"`
+	CustomerCity string `json:"customer_city"`
+	CallDate     string `json:"call_date"`
+	InsightID    int64  `json:"insight_id"`
+}
+
+// Subject is the one-line summary used as mail subject and message heading
+func (n Notification) Subject() string {
+	return fmt.Sprintf("[%s] %s alert for seller %d", strings.ToUpper(n.Severity), n.AlertType, n.Glid)
+}
+
+// Text is the plain-text body of the notification
+func (n Notification) Text() string {
+	lines := []string{
+		n.Description,
+		"",
+		fmt.Sprintf("Seller (GLID): %d", n.Glid),
+		"Executive: " + n.ExecutiveID,
+		"Customer: " + strings.Trim(n.CustomerType+", "+n.CustomerCity, ", "),
+		"Call date: " + n.CallDate,
+		fmt.Sprintf("Insight: %d", n.InsightID),
+		"Rule: " + n.Rule,
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Notifier delivers notifications to one destination
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, notification Notification) error
+}
+
+var (
+	notifiersMu sync.RWMutex
+	notifiers   = map[string]Notifier{}
+)
+
+// Configure replaces the notifiers with the ones of cfg.Notifiers
+func Configure(cfg config.AlertsConfig) {
+	configured := map[string]Notifier{}
+	for _, notifierCfg := range cfg.Notifiers {
+		switch notifierCfg.Type {
+		case TypeWebhook:
+			configured[notifierCfg.Name] = NewWebhookNotifier(notifierCfg)
+		case TypeSlack:
+			configured[notifierCfg.Name] = NewSlackNotifier(notifierCfg)
+		case TypeEmail:
+			configured[notifierCfg.Name] = NewEmailNotifier(notifierCfg)
+		}
+	}
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	notifiers = configured
+}
+
+// Register adds or replaces a notifier under its Name()
+func Register(notifier Notifier) {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	notifiers[notifier.Name()] = notifier
+}
+
+// Get returns the named notifier
+func Get(name string) (Notifier, error) {
+	notifiersMu.RLock()
+	defer notifiersMu.RUnlock()
+	notifier, ok := notifiers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown notifier %q", name)
+	}
+	return notifier, nil
+}
@@ -0,0 +1,244 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"voice-hack-backend/config"
+)
+
+var testNotification = Notification{
+	DeliveryID:   7,
+	Rule:         "churn-high",
+	AlertType:    "churn_risk",
+	Severity:     "high",
+	Description:  "Seller mentions moving to a competitor",
+	Glid:         42,
+	ExecutiveID:  "E1",
+	CustomerType: "Existing",
+	CustomerCity: "Delhi",
+	CallDate:     "2026-10-17",
+	InsightID:    3,
+}
+
+// recordedRequest is one request received by a stand-in webhook
+type recordedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// startWebhook serves status to every request and records them
+func startWebhook(t *testing.T, status int) (*httptest.Server, <-chan recordedRequest) {
+	t.Helper()
+	requests := make(chan recordedRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- recordedRequest{header: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+		w.Write([]byte("stand-in says " + http.StatusText(status)))
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestWebhookNotifierPostsNotification(t *testing.T) {
+	server, requests := startWebhook(t, http.StatusOK)
+	webhook := NewWebhookNotifier(config.AlertNotifierConfig{Name: "hook", URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+
+	if err := webhook.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	request := <-requests
+	if got := request.header.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("Authorization header = %q", got)
+	}
+	if got := request.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	var posted Notification
+	if err := json.Unmarshal(request.body, &posted); err != nil {
+		t.Fatalf("body is not a notification: %v: %s", err, request.body)
+	}
+	if posted != testNotification {
+		t.Errorf("posted %+v, want %+v", posted, testNotification)
+	}
+}
+
+func TestWebhookNotifierFailsOutside2xx(t *testing.T) {
+	server, _ := startWebhook(t, http.StatusBadGateway)
+	webhook := NewWebhookNotifier(config.AlertNotifierConfig{Name: "hook", URL: server.URL})
+
+	err := webhook.Notify(context.Background(), testNotification)
+	if err == nil || !strings.Contains(err.Error(), "502") || !strings.Contains(err.Error(), "stand-in says") {
+		t.Fatalf("Notify error = %v, want the status and response body", err)
+	}
+}
+
+func TestSlackNotifierPostsText(t *testing.T) {
+	server, requests := startWebhook(t, http.StatusOK)
+	slack := NewSlackNotifier(config.AlertNotifierConfig{Name: "slack", URL: server.URL})
+
+	if err := slack.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal((<-requests).body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload) != 1 {
+		t.Errorf("payload has fields %v, want only text", payload)
+	}
+	text, _ := payload["text"].(string)
+	if !strings.HasPrefix(text, "*[HIGH] churn_risk alert for seller 42*\n") {
+		t.Errorf("text does not start with the bold subject: %q", text)
+	}
+	for _, want := range []string{testNotification.Description, "Executive: E1", "Customer: Existing, Delhi", "Rule: churn-high"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text misses %q: %q", want, text)
+		}
+	}
+}
+
+// smtpSession is what a stand-in SMTP server received on one connection
+type smtpSession struct {
+	commands []string
+	data     string
+}
+
+// startSMTP accepts connections on a local port and answers like a minimal SMTP server without
+// STARTTLS or AUTH. rcptCode is the reply to RCPT TO.
+func startSMTP(t *testing.T, rcptCode string) (string, int, <-chan smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	sessions := make(chan smtpSession, 10)
+	go func() {
+		for {
+			conn, acceptErr := listener.Accept()
+			if acceptErr != nil {
+				return
+			}
+			go serveSMTP(conn, rcptCode, sessions)
+		}
+	}()
+	address := listener.Addr().(*net.TCPAddr)
+	return address.IP.String(), address.Port, sessions
+}
+
+func serveSMTP(conn net.Conn, rcptCode string, sessions chan<- smtpSession) {
+	defer conn.Close()
+	var session smtpSession
+	defer func() { sessions <- session }()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 stand-in ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimRight(line, "\r\n")
+		session.commands = append(session.commands, command)
+		switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 stand-in")
+		case "RCPT":
+			reply(rcptCode + " recipient")
+		case "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				dataLine, dataErr := reader.ReadString('\n')
+				if dataErr != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			session.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestEmailNotifierSendsMail(t *testing.T) {
+	host, port, sessions := startSMTP(t, "250")
+	email := NewEmailNotifier(config.AlertNotifierConfig{
+		Name: "mail", SMTPHost: host, SMTPPort: port, From: "alerts@example.com", To: []string{"sales@example.com", "vp@example.com"},
+	})
+
+	if err := email.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	session := <-sessions
+	conversation := strings.Join(session.commands, "\n")
+	for _, want := range []string{"MAIL FROM:<alerts@example.com>", "RCPT TO:<sales@example.com>", "RCPT TO:<vp@example.com>", "DATA", "QUIT"} {
+		if !strings.Contains(conversation, want) {
+			t.Errorf("conversation misses %q:\n%s", want, conversation)
+		}
+	}
+	if strings.Contains(conversation, "AUTH") || strings.Contains(conversation, "STARTTLS") {
+		t.Errorf("AUTH or STARTTLS sent to a server offering neither:\n%s", conversation)
+	}
+	for _, want := range []string{
+		"From: alerts@example.com\r\n",
+		"To: sales@example.com, vp@example.com\r\n",
+		"Subject: [HIGH] churn_risk alert for seller 42\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n\r\n" + testNotification.Description + "\r\n",
+		"Insight: 3\r\n",
+	} {
+		if !strings.Contains(session.data, want) {
+			t.Errorf("mail misses %q:\n%s", want, session.data)
+		}
+	}
+}
+
+func TestEmailNotifierReportsRejectedRecipient(t *testing.T) {
+	host, port, _ := startSMTP(t, "550")
+	email := NewEmailNotifier(config.AlertNotifierConfig{Name: "mail", SMTPHost: host, SMTPPort: port, From: "a@example.com", To: []string{"b@example.com"}})
+
+	err := email.Notify(context.Background(), testNotification)
+	if err == nil || !strings.Contains(err.Error(), "RCPT TO b@example.com") {
+		t.Fatalf("Notify error = %v, want the rejected recipient", err)
+	}
+}
+
+func TestConfigureReplacesNotifiers(t *testing.T) {
+	Register(NewSlackNotifier(config.AlertNotifierConfig{Name: "stale"}))
+	Configure(config.AlertsConfig{Notifiers: []config.AlertNotifierConfig{
+		{Name: "hook", Type: TypeWebhook},
+		{Name: "slack", Type: TypeSlack},
+		{Name: "mail", Type: TypeEmail},
+	}})
+
+	for name, want := range map[string]string{"hook": "*notifier.WebhookNotifier", "slack": "*notifier.SlackNotifier", "mail": "*notifier.EmailNotifier"} {
+		got, err := Get(name)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", name, err)
+		}
+		if fmt.Sprintf("%T", got) != want {
+			t.Errorf("Get(%q) is a %T, want %s", name, got, want)
+		}
+	}
+	if _, err := Get("stale"); err == nil {
+		t.Error("Configure kept a notifier that is no longer configured")
+	}
+}
@@ -0,0 +1,77 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"voice-hack-backend/config"
+)
+
+// WebhookNotifier posts the notification as JSON to a URL
+type WebhookNotifier struct {
+	name    string
+	URL     string
+	Headers map[string]string
+}
+
+func NewWebhookNotifier(cfg config.AlertNotifierConfig) *WebhookNotifier {
+	return &WebhookNotifier{name: cfg.Name, URL: cfg.URL, Headers: cfg.Headers}
+}
+
+func (n *WebhookNotifier) Name() string {
+	return n.name
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	return postJSON(ctx, n.URL, n.Headers, notification)
+}
+
+// SlackNotifier posts the notification to a Slack-compatible incoming webhook
+type SlackNotifier struct {
+	name string
+	URL  string
+}
+
+func NewSlackNotifier(cfg config.AlertNotifierConfig) *SlackNotifier {
+	return &SlackNotifier{name: cfg.Name, URL: cfg.URL}
+}
+
+func (n *SlackNotifier) Name() string {
+	return n.name
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, notification Notification) error {
+	message := map[string]string{"text": "*" + notification.Subject() + "*\n" + notification.Text()}
+	return postJSON(ctx, n.URL, nil, message)
+}
+
+// postJSON sends body to url, any status outside 2xx is an error
+func postJSON(ctx context.Context, url string, headers map[string]string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
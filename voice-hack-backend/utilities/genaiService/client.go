@@ -2,12 +2,19 @@ package genaiService
 
 import (
 	"context"
-	globalconstant "voice-hack-backend/globalConstant"
+	"voice-hack-backend/config"
 
 	"google.golang.org/genai"
 )
 
 var genaiClient *genai.Client
+var geminiConfig = config.Defaults().Gemini
+
+// Configure sets the Gemini credentials and drops any client built with the old ones
+func Configure(cfg config.GeminiConfig) {
+	geminiConfig = cfg
+	genaiClient = nil
+}
 
 func GetClient() (client *genai.Client, err error) {
 	if genaiClient != nil {
@@ -15,7 +22,7 @@ func GetClient() (client *genai.Client, err error) {
 		return
 	}
 	clientConfig := genai.ClientConfig{
-		APIKey: globalconstant.GEMINI_API_KEY,
+		APIKey: geminiConfig.APIKey,
 	}
 	client, clientErr := genai.NewClient(context.Background(), &clientConfig)
 	if clientErr != nil {
@@ -1,14 +1,14 @@
 package genaiService
 
 import (
+	"context"
 	"fmt"
 
-	"github.com/gin-gonic/gin"
 	"google.golang.org/genai"
 )
 
-func ListAvailableModels(ginCtx *gin.Context, client *genai.Client) (availableModels []string, err error) {
-	modelList, modelListErr := client.Models.List(ginCtx, &genai.ListModelsConfig{})
+func ListAvailableModels(ctx context.Context, client *genai.Client) (availableModels []string, err error) {
+	modelList, modelListErr := client.Models.List(ctx, &genai.ListModelsConfig{})
 	if modelListErr != nil {
 		err = modelListErr
 		return
@@ -27,8 +27,8 @@ func ListAvailableModels(ginCtx *gin.Context, client *genai.Client) (availableMo
 	return
 }
 
-func IsModelAvailable(ginCtx *gin.Context, client *genai.Client, model string) (err error) {
-	availableModels, availableModelsErr := ListAvailableModels(ginCtx, client)
+func IsModelAvailable(ctx context.Context, client *genai.Client, model string) (err error) {
+	availableModels, availableModelsErr := ListAvailableModels(ctx, client)
 	if availableModelsErr != nil {
 		return availableModelsErr
 	}
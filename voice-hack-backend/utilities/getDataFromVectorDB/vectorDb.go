@@ -9,36 +9,39 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/redaction"
 
 	pc "github.com/pinecone-io/go-pinecone/pinecone"
 )
 
-//"pcsk_6VcVxq_QvKbhRAvWRg8iAJDUdY6STdzHaRckmwRTsqPrEQs7HfuudesdDsAFQiUw1kqovh"
-// "sk-gDgbFkH2KH-HKvX2a4uEXw"
 // ===============================
-//        CONFIG
+//
+//	CONFIG
+//
 // ===============================
-var (
-	LLM_GATEWAY_KEY = ""  // Replace with your key
-	PINECONE_KEY    = ""  // Replace with your key
-	PINECONE_HOST   = "https://insights-fv7quol.svc.aped-4627-b74a.pinecone.io"
-	PINECONE_INDEX  = "insights"
-	NAMESPACE       = "default"
-)
+var vectorDBConfig = config.Defaults().VectorDB
+
+// Configure sets the Pinecone and embedding settings used by every query
+func Configure(cfg config.VectorDBConfig) {
+	vectorDBConfig = cfg
+}
 
 // ===============================
-//      EMBEDDING FUNCTION
+//
+//	EMBEDDING FUNCTION
+//
 // ===============================
 func GetEmbedding(text string) ([]float32, error) {
-	url := "https://imllm.intermesh.net/v1/embeddings"
+	url := vectorDBConfig.EmbeddingURL
 	body := map[string]interface{}{
-		"model": "google/gemini-embedding-001",
-		"input": text,
+		"model": vectorDBConfig.EmbeddingModel,
+		"input": redaction.Redact(redaction.DestinationVectorDB, text),
 	}
 	bodyBytes, _ := json.Marshal(body)
 
 	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(bodyBytes))
-	req.Header.Set("Authorization", "Bearer "+LLM_GATEWAY_KEY)
+	req.Header.Set("Authorization", "Bearer "+vectorDBConfig.EmbeddingAPIKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{}
@@ -71,7 +74,9 @@ func GetEmbedding(text string) ([]float32, error) {
 }
 
 // ===============================
-//      VECTOR DB STRUCT
+//
+//	VECTOR DB STRUCT
+//
 // ===============================
 type VectorDB struct {
 	Client *pc.Client
@@ -83,16 +88,16 @@ func NewVectorDB() *VectorDB {
 	// ctx := context.Background()
 
 	client, err := pc.NewClient(pc.NewClientParams{
-		ApiKey: PINECONE_KEY,
-		Host:   PINECONE_HOST,
+		ApiKey: vectorDBConfig.PineconeAPIKey,
+		Host:   vectorDBConfig.PineconeHost,
 	})
 	if err != nil {
 		log.Fatalf("failed to create Pinecone client: %v", err)
 	}
 
 	idxConn, err := client.Index(pc.NewIndexConnParams{
-		Host:      PINECONE_HOST,
-		Namespace: NAMESPACE,
+		Host:      vectorDBConfig.PineconeHost,
+		Namespace: vectorDBConfig.Namespace,
 	})
 	if err != nil {
 		log.Fatalf("failed to connect to Pinecone index: %v", err)
@@ -148,7 +153,6 @@ func (vdb *VectorDB) QueryText(query string, topK int) ([]string, error) {
 	return results, nil
 }
 
-
 func GetSampleCalls(text string, topK int) (string, string) {
 	vdb := NewVectorDB()
 
@@ -162,4 +166,3 @@ func GetSampleCalls(text string, topK int) (string, string) {
 	}
 	return ans, ""
 }
-
@@ -0,0 +1,197 @@
+package insightStore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Stored action item statuses; an open item past its due date is reported as overdue by the models
+const (
+	ActionItemOpen = "open"
+	ActionItemDone = "done"
+)
+
+// ActionItem is one next step of a call-level insight tracked until it is done
+type ActionItem struct {
+	ID          int64      `json:"id"`
+	InsightID   int64      `json:"insight_id"`
+	Glid        int        `json:"glid"`
+	ExecutiveID string     `json:"executive_id"`
+	Owner       string     `json:"owner"` // persona responsible, e.g. executive or seller
+	Description string     `json:"description"`
+	CallDate    string     `json:"call_date"` // YYYY-MM-DD, empty when the call date was unknown
+	DueDate     string     `json:"due_date"`  // YYYY-MM-DD
+	Status      string     `json:"status"`    // ActionItemOpen or ActionItemDone
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ActionItemFilter narrows ListActionItems, zero values match everything
+type ActionItemFilter struct {
+	Glid        int
+	ExecutiveID string
+	Owner       string
+	Status      string
+	FromDate    string // due on or after, YYYY-MM-DD
+	ToDate      string // due on or before, YYYY-MM-DD
+	DueBefore   string // due strictly before, YYYY-MM-DD
+	Limit       int
+}
+
+// ActionItemRepository stores the action items tracked from stored insights
+type ActionItemRepository interface {
+	SaveActionItems(ctx context.Context, items []ActionItem) ([]int64, error)
+	ListActionItems(ctx context.Context, filter ActionItemFilter) ([]ActionItem, error)
+	GetActionItem(ctx context.Context, id int64) (ActionItem, error)
+	UpdateActionItem(ctx context.Context, item ActionItem) error
+}
+
+const actionItemColumns = `id, insight_id, glid, executive_id, owner, description, call_date, due_date, status, completed_at, created_at, updated_at`
+
+// SaveActionItems inserts items, open unless their status says otherwise
+func (r *SQLiteRepository) SaveActionItems(ctx context.Context, items []ActionItem) ([]int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO action_items (insight_id, glid, executive_id, owner, description, call_date, due_date,
+		status, completed_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	ids := make([]int64, 0, len(items))
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, item := range items {
+		if item.Status == "" {
+			item.Status = ActionItemOpen
+		}
+		result, execErr := stmt.ExecContext(ctx, item.InsightID, item.Glid, item.ExecutiveID, item.Owner, item.Description, item.CallDate,
+			item.DueDate, item.Status, formatOptionalTime(item.CompletedAt), now, now)
+		if execErr != nil {
+			return nil, fmt.Errorf("failed to insert action item: %w", execErr)
+		}
+		id, idErr := result.LastInsertId()
+		if idErr != nil {
+			return nil, idErr
+		}
+		ids = append(ids, id)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ListActionItems returns matching items, earliest due first
+func (r *SQLiteRepository) ListActionItems(ctx context.Context, filter ActionItemFilter) ([]ActionItem, error) {
+	where, args := filter.whereClause()
+	query := `SELECT ` + actionItemColumns + ` FROM action_items` + where + ` ORDER BY due_date, id`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query action items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []ActionItem{}
+	for rows.Next() {
+		item, scanErr := scanActionItem(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetActionItem returns the item with id, failing with ErrNotFound when there is none
+func (r *SQLiteRepository) GetActionItem(ctx context.Context, id int64) (ActionItem, error) {
+	item, err := scanActionItem(r.db.QueryRowContext(ctx, `SELECT `+actionItemColumns+` FROM action_items WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return item, fmt.Errorf("action item %d %w", id, ErrNotFound)
+	}
+	return item, err
+}
+
+// UpdateActionItem saves the owner, description, due date and status of the item with the same id,
+// failing with ErrNotFound when there is none
+func (r *SQLiteRepository) UpdateActionItem(ctx context.Context, item ActionItem) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE action_items SET owner = ?, description = ?, due_date = ?, status = ?, completed_at = ?,
+		updated_at = ? WHERE id = ?`,
+		item.Owner, item.Description, item.DueDate, item.Status, formatOptionalTime(item.CompletedAt), time.Now().UTC().Format(time.RFC3339Nano), item.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update action item: %w", err)
+	}
+	return expectOneRow(result, fmt.Sprintf("action item %d", item.ID))
+}
+
+func (filter ActionItemFilter) whereClause() (string, []any) {
+	var conditions []string
+	var args []any
+	add := func(condition string, value any) {
+		conditions = append(conditions, condition)
+		args = append(args, value)
+	}
+	if filter.Glid > 0 {
+		add("glid = ?", filter.Glid)
+	}
+	if filter.ExecutiveID != "" {
+		add("executive_id = ?", filter.ExecutiveID)
+	}
+	if filter.Owner != "" {
+		add("owner = ?", filter.Owner)
+	}
+	if filter.Status != "" {
+		add("status = ?", filter.Status)
+	}
+	if filter.FromDate != "" {
+		add("due_date >= ?", filter.FromDate)
+	}
+	if filter.ToDate != "" {
+		add("due_date <= ?", filter.ToDate)
+	}
+	if filter.DueBefore != "" {
+		add("due_date < ?", filter.DueBefore)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// formatOptionalTime encodes a nullable time column, an empty string for nil
+func formatOptionalTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.UTC().Format(time.RFC3339Nano)
+}
+
+func scanActionItem(row rowScanner) (ActionItem, error) {
+	var item ActionItem
+	var completedAt, createdAt, updatedAt string
+	err := row.Scan(&item.ID, &item.InsightID, &item.Glid, &item.ExecutiveID, &item.Owner, &item.Description, &item.CallDate,
+		&item.DueDate, &item.Status, &completedAt, &createdAt, &updatedAt)
+	if err != nil {
+		return item, fmt.Errorf("failed to scan action item: %w", err)
+	}
+	if completed, parseErr := time.Parse(time.RFC3339Nano, completedAt); parseErr == nil {
+		item.CompletedAt = &completed
+	}
+	item.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+	item.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+	return item, nil
+}
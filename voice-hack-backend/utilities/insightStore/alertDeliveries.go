@@ -0,0 +1,217 @@
+package insightStore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Alert delivery statuses
+const (
+	DeliveryPending    = "pending"    // waiting for its next attempt
+	DeliverySent       = "sent"       // accepted by the notifier
+	DeliveryFailed     = "failed"     // every attempt failed
+	DeliverySuppressed = "suppressed" // the same alert of the seller went to the notifier within the dedupe window
+)
+
+// AlertDelivery is one alert of a stored insight routed by a rule to a notifier
+type AlertDelivery struct {
+	ID            int64      `json:"id"`
+	InsightID     int64      `json:"insight_id"`
+	Glid          int        `json:"glid"`
+	ExecutiveID   string     `json:"executive_id"`
+	CustomerType  string     `json:"customer_type"`
+	CustomerCity  string     `json:"customer_city"`
+	CallDate      string     `json:"call_date"`
+	Rule          string     `json:"rule"`
+	Notifier      string     `json:"notifier"`
+	AlertType     string     `json:"alert_type"`
+	Severity      string     `json:"severity"`
+	Description   string     `json:"description"`
+	DedupeKey     string     `json:"-"` // glid, alert type and notifier
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"` // pending deliveries only
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// AlertDeliveryFilter narrows ListAlertDeliveries, zero values match everything
+type AlertDeliveryFilter struct {
+	Glid     int
+	Notifier string
+	Status   string
+	Limit    int
+}
+
+// AlertDeliveryRepository stores the alert deliveries and their attempts
+type AlertDeliveryRepository interface {
+	SaveAlertDeliveries(ctx context.Context, deliveries []AlertDelivery) ([]int64, error)
+	ListAlertDeliveries(ctx context.Context, filter AlertDeliveryFilter) ([]AlertDelivery, error)
+	GetAlertDelivery(ctx context.Context, id int64) (AlertDelivery, error)
+	DueAlertDeliveries(ctx context.Context, now time.Time, limit int) ([]AlertDelivery, error)
+	AlertDeliveredSince(ctx context.Context, dedupeKey string, since time.Time) (bool, error)
+	UpdateAlertDelivery(ctx context.Context, delivery AlertDelivery) error
+}
+
+// deliveryTimeLayout is fixed width so stored attempt and creation times compare as text
+const deliveryTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+const alertDeliveryColumns = `id, insight_id, glid, executive_id, customer_type, customer_city, call_date, rule, notifier, alert_type, severity,
+	description, dedupe_key, status, attempts, last_error, next_attempt_at, sent_at, created_at, updated_at`
+
+// SaveAlertDeliveries inserts deliveries with their status and first attempt time
+func (r *SQLiteRepository) SaveAlertDeliveries(ctx context.Context, deliveries []AlertDelivery) ([]int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO alert_deliveries (insight_id, glid, executive_id, customer_type, customer_city, call_date,
+		rule, notifier, alert_type, severity, description, dedupe_key, status, attempts, last_error, next_attempt_at, sent_at, created_at,
+		updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	ids := make([]int64, 0, len(deliveries))
+	now := time.Now().UTC().Format(deliveryTimeLayout)
+	for _, delivery := range deliveries {
+		result, execErr := stmt.ExecContext(ctx, delivery.InsightID, delivery.Glid, delivery.ExecutiveID, delivery.CustomerType,
+			delivery.CustomerCity, delivery.CallDate, delivery.Rule, delivery.Notifier, delivery.AlertType, delivery.Severity,
+			delivery.Description, delivery.DedupeKey, delivery.Status, delivery.Attempts, delivery.LastError,
+			formatDeliveryTime(delivery.NextAttemptAt), formatDeliveryTime(delivery.SentAt), now, now)
+		if execErr != nil {
+			return nil, fmt.Errorf("failed to insert alert delivery: %w", execErr)
+		}
+		id, idErr := result.LastInsertId()
+		if idErr != nil {
+			return nil, idErr
+		}
+		ids = append(ids, id)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ListAlertDeliveries returns matching deliveries, newest first
+func (r *SQLiteRepository) ListAlertDeliveries(ctx context.Context, filter AlertDeliveryFilter) ([]AlertDelivery, error) {
+	var conditions []string
+	var args []any
+	if filter.Glid > 0 {
+		conditions = append(conditions, "glid = ?")
+		args = append(args, filter.Glid)
+	}
+	if filter.Notifier != "" {
+		conditions = append(conditions, "notifier = ?")
+		args = append(args, filter.Notifier)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	query := `SELECT ` + alertDeliveryColumns + ` FROM alert_deliveries`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+	return r.queryAlertDeliveries(ctx, query, args...)
+}
+
+// GetAlertDelivery returns the delivery with id, failing with ErrNotFound when there is none
+func (r *SQLiteRepository) GetAlertDelivery(ctx context.Context, id int64) (AlertDelivery, error) {
+	delivery, err := scanAlertDelivery(r.db.QueryRowContext(ctx, `SELECT `+alertDeliveryColumns+` FROM alert_deliveries WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return delivery, fmt.Errorf("alert delivery %d %w", id, ErrNotFound)
+	}
+	return delivery, err
+}
+
+// DueAlertDeliveries returns up to limit pending deliveries whose next attempt is due at now, oldest first
+func (r *SQLiteRepository) DueAlertDeliveries(ctx context.Context, now time.Time, limit int) ([]AlertDelivery, error) {
+	return r.queryAlertDeliveries(ctx, `SELECT `+alertDeliveryColumns+` FROM alert_deliveries WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, id LIMIT ?`, DeliveryPending, now.UTC().Format(deliveryTimeLayout), limit)
+}
+
+// AlertDeliveredSince reports whether a pending or sent delivery with dedupeKey was created at or after since
+func (r *SQLiteRepository) AlertDeliveredSince(ctx context.Context, dedupeKey string, since time.Time) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM alert_deliveries WHERE dedupe_key = ? AND status IN (?, ?) AND created_at >= ?`,
+		dedupeKey, DeliveryPending, DeliverySent, since.UTC().Format(deliveryTimeLayout)).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to query alert deliveries: %w", err)
+	}
+	return count > 0, nil
+}
+
+// UpdateAlertDelivery saves the status and attempt fields of the delivery with the same id,
+// failing with ErrNotFound when there is none
+func (r *SQLiteRepository) UpdateAlertDelivery(ctx context.Context, delivery AlertDelivery) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE alert_deliveries SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, sent_at = ?,
+		updated_at = ? WHERE id = ?`,
+		delivery.Status, delivery.Attempts, delivery.LastError, formatDeliveryTime(delivery.NextAttemptAt), formatDeliveryTime(delivery.SentAt),
+		time.Now().UTC().Format(deliveryTimeLayout), delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update alert delivery: %w", err)
+	}
+	return expectOneRow(result, fmt.Sprintf("alert delivery %d", delivery.ID))
+}
+
+func (r *SQLiteRepository) queryAlertDeliveries(ctx context.Context, query string, args ...any) ([]AlertDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []AlertDelivery{}
+	for rows.Next() {
+		delivery, scanErr := scanAlertDelivery(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// formatDeliveryTime encodes a nullable time column, an empty string for nil
+func formatDeliveryTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.UTC().Format(deliveryTimeLayout)
+}
+
+func scanAlertDelivery(row rowScanner) (AlertDelivery, error) {
+	var delivery AlertDelivery
+	var nextAttemptAt, sentAt, createdAt, updatedAt string
+	err := row.Scan(&delivery.ID, &delivery.InsightID, &delivery.Glid, &delivery.ExecutiveID, &delivery.CustomerType, &delivery.CustomerCity,
+		&delivery.CallDate, &delivery.Rule, &delivery.Notifier, &delivery.AlertType, &delivery.Severity, &delivery.Description,
+		&delivery.DedupeKey, &delivery.Status, &delivery.Attempts, &delivery.LastError, &nextAttemptAt, &sentAt, &createdAt, &updatedAt)
+	if err != nil {
+		return delivery, fmt.Errorf("failed to scan alert delivery: %w", err)
+	}
+	if parsed, parseErr := time.Parse(time.RFC3339Nano, nextAttemptAt); parseErr == nil {
+		delivery.NextAttemptAt = &parsed
+	}
+	if parsed, parseErr := time.Parse(time.RFC3339Nano, sentAt); parseErr == nil {
+		delivery.SentAt = &parsed
+	}
+	delivery.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+	delivery.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+	return delivery, nil
+}
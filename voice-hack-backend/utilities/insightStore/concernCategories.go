@@ -0,0 +1,144 @@
+package insightStore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrExists   = errors.New("already exists")
+)
+
+// ConcernCategory is one editable row of the concern/resolution knowledge base
+type ConcernCategory struct {
+	Code             string    `json:"code"` // snake_case, used in the typed insights
+	Label            string    `json:"label"`
+	Description      string    `json:"description"`
+	Resolution       string    `json:"resolution"` // what the executive is expected to do on the call
+	ExecutiveActions []string  `json:"executive_actions"`
+	SellerActions    []string  `json:"seller_actions"`
+	EscalationTeam   string    `json:"escalation_team"`
+	Keywords         []string  `json:"keywords"` // classify free-text v1 concerns, lower case
+	Position         int       `json:"position"` // order in the prompt and the enum
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ConcernRepository stores the concern categories of the knowledge base
+type ConcernRepository interface {
+	ListConcernCategories(ctx context.Context) ([]ConcernCategory, error)
+	CreateConcernCategory(ctx context.Context, category ConcernCategory) error
+	UpdateConcernCategory(ctx context.Context, category ConcernCategory) error
+	DeleteConcernCategory(ctx context.Context, code string) error
+}
+
+const concernColumns = `code, label, description, resolution, executive_actions, seller_actions, escalation_team, keywords, position, created_at, updated_at`
+
+// ListConcernCategories returns every category by position
+func (r *SQLiteRepository) ListConcernCategories(ctx context.Context) ([]ConcernCategory, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+concernColumns+` FROM concern_categories ORDER BY position, code`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query concern categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []ConcernCategory{}
+	for rows.Next() {
+		category, scanErr := scanConcernCategory(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+// CreateConcernCategory inserts category, failing with ErrExists when its code is taken
+func (r *SQLiteRepository) CreateConcernCategory(ctx context.Context, category ConcernCategory) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	values := concernValues(category)
+	_, err := r.db.ExecContext(ctx, `INSERT INTO concern_categories (`+concernColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		append(values, now, now)...)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return fmt.Errorf("concern category %s %w", category.Code, ErrExists)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert concern category: %w", err)
+	}
+	return nil
+}
+
+// UpdateConcernCategory replaces the category with the same code, failing with ErrNotFound when there is none
+func (r *SQLiteRepository) UpdateConcernCategory(ctx context.Context, category ConcernCategory) error {
+	values := concernValues(category)
+	result, err := r.db.ExecContext(ctx, `UPDATE concern_categories SET label = ?, description = ?, resolution = ?, executive_actions = ?,
+		seller_actions = ?, escalation_team = ?, keywords = ?, position = ?, updated_at = ? WHERE code = ?`,
+		append(values[1:], time.Now().UTC().Format(time.RFC3339Nano), category.Code)...)
+	if err != nil {
+		return fmt.Errorf("failed to update concern category: %w", err)
+	}
+	return expectOneRow(result, "concern category "+category.Code)
+}
+
+// DeleteConcernCategory removes the category with code, failing with ErrNotFound when there is none
+func (r *SQLiteRepository) DeleteConcernCategory(ctx context.Context, code string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM concern_categories WHERE code = ?`, code)
+	if err != nil {
+		return fmt.Errorf("failed to delete concern category: %w", err)
+	}
+	return expectOneRow(result, "concern category "+code)
+}
+
+// concernValues returns the columns of category from code to position
+func concernValues(category ConcernCategory) []any {
+	return []any{category.Code, category.Label, category.Description, category.Resolution, jsonList(category.ExecutiveActions),
+		jsonList(category.SellerActions), category.EscalationTeam, jsonList(category.Keywords), category.Position}
+}
+
+func expectOneRow(result sql.Result, what string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s %w", what, ErrNotFound)
+	}
+	return nil
+}
+
+// jsonList encodes a list column, an empty list for nil
+func jsonList(values []string) string {
+	if values == nil {
+		values = []string{}
+	}
+	encoded, _ := json.Marshal(values)
+	return string(encoded)
+}
+
+func scanConcernCategory(row rowScanner) (ConcernCategory, error) {
+	var category ConcernCategory
+	var executiveActions, sellerActions, keywords, createdAt, updatedAt string
+	err := row.Scan(&category.Code, &category.Label, &category.Description, &category.Resolution, &executiveActions, &sellerActions,
+		&category.EscalationTeam, &keywords, &category.Position, &createdAt, &updatedAt)
+	if err != nil {
+		return category, fmt.Errorf("failed to scan concern category: %w", err)
+	}
+	columns := []struct {
+		raw  string
+		list *[]string
+	}{{executiveActions, &category.ExecutiveActions}, {sellerActions, &category.SellerActions}, {keywords, &category.Keywords}}
+	for _, column := range columns {
+		if err := json.Unmarshal([]byte(column.raw), column.list); err != nil {
+			return category, fmt.Errorf("failed to decode concern category %s: %w", category.Code, err)
+		}
+	}
+	category.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+	category.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+	return category, nil
+}
@@ -0,0 +1,105 @@
+package insightStore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StoredInsight is one call-level insight persisted for a seller
+type StoredInsight struct {
+	ID             int64     `json:"id"`
+	Glid           int       `json:"glid"`
+	ExecutiveID    string    `json:"executive_id"`
+	CustomerType   string    `json:"customer_type"`
+	CustomerCity   string    `json:"customer_city_name"`
+	CallIndex      int       `json:"call_index"` // 1-based position of the call in its request
+	CallType       string    `json:"call_type"`
+	SourceURL      string    `json:"source_url,omitempty"` // call_recording_url or transcript_url of the call
+	CallDate       string    `json:"call_date"`            // YYYY-MM-DD when the input date could be parsed
+	InsightType    string    `json:"insight_type"`
+	Concerns       string    `json:"concerns"`
+	Resolution     string    `json:"resolution"`
+	NextSteps      string    `json:"next_steps"`
+	Alert          string    `json:"alert"`
+	Sentiment      string    `json:"sentiment"`
+	KeyPoints      string    `json:"key_points"`
+	Model          string    `json:"model"`
+	PromptVersion  string    `json:"prompt_version"`
+	StructuredJSON string    `json:"-"`        // typed v2 insight, empty for v1
+	MetricsJSON    string    `json:"-"`        // measured call metrics, empty when the transcript had no timestamps
+	Language       string    `json:"language"` // detected language code of the call
+	ComplianceJSON string    `json:"-"`        // resolution compliance check, empty when the call was not checked
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// InsightFilter narrows ListInsights, zero values match everything
+type InsightFilter struct {
+	Glids        []int
+	ExecutiveID  string
+	CustomerType string // case-insensitive
+	CustomerCity string // case-insensitive
+	InsightType  string
+	FromDate     string // inclusive, YYYY-MM-DD
+	ToDate       string // inclusive, YYYY-MM-DD
+	Limit        int
+}
+
+// Repository stores and queries call-level insights, the knowledge base they are generated with, and the
+// action items and alert deliveries tracked from them
+type Repository interface {
+	SaveInsights(ctx context.Context, insights []StoredInsight) ([]int64, error)
+	ListInsights(ctx context.Context, filter InsightFilter) ([]StoredInsight, error)
+	ConcernRepository
+	ActionItemRepository
+	AlertDeliveryRepository
+	Close() error
+}
+
+var (
+	defaultMu   sync.RWMutex
+	defaultRepo Repository
+)
+
+// SetDefault installs the repository used by the models
+func SetDefault(repo Repository) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultRepo = repo
+}
+
+// Default returns the repository installed with SetDefault
+func Default() (Repository, error) {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	if defaultRepo == nil {
+		return nil, errors.New("insight store is not initialised")
+	}
+	return defaultRepo, nil
+}
+
+// callDateLayouts are the call_date formats accepted from callers
+var callDateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+	"2006/01/02",
+	"02-01-2006",
+	"02/01/2006",
+	"02-01-2006 15:04:05",
+	"02 Jan 2006",
+	"Jan 2, 2006",
+}
+
+// NormalizeCallDate converts a call date to YYYY-MM-DD, reporting whether it could be parsed
+func NormalizeCallDate(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range callDateLayouts {
+		if parsed, err := time.Parse(layout, raw); err == nil {
+			return parsed.Format("2006-01-02"), true
+		}
+	}
+	return raw, false
+}
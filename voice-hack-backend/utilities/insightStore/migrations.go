@@ -0,0 +1,174 @@
+package insightStore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migrations are applied in order, each exactly once. Append new ones, never edit old ones.
+var migrations = []string{
+	// 1: call-level insights
+	`CREATE TABLE insights (
+		id             INTEGER PRIMARY KEY AUTOINCREMENT,
+		glid           INTEGER NOT NULL,
+		executive_id   TEXT    NOT NULL DEFAULT '',
+		customer_type  TEXT    NOT NULL DEFAULT '',
+		customer_city  TEXT    NOT NULL DEFAULT '',
+		call_index     INTEGER NOT NULL,
+		call_type      TEXT    NOT NULL DEFAULT '',
+		call_date      TEXT    NOT NULL DEFAULT '',
+		insight_type   TEXT    NOT NULL DEFAULT '',
+		concerns       TEXT    NOT NULL DEFAULT '',
+		resolution     TEXT    NOT NULL DEFAULT '',
+		next_steps     TEXT    NOT NULL DEFAULT '',
+		alert          TEXT    NOT NULL DEFAULT '',
+		sentiment      TEXT    NOT NULL DEFAULT '',
+		key_points     TEXT    NOT NULL DEFAULT '',
+		model          TEXT    NOT NULL DEFAULT '',
+		prompt_version TEXT    NOT NULL DEFAULT '',
+		created_at     TEXT    NOT NULL
+	);
+	CREATE INDEX idx_insights_glid_date ON insights (glid, call_date);
+	CREATE INDEX idx_insights_executive_date ON insights (executive_id, call_date);`,
+	// 2: typed v2 insight as JSON, empty for v1 rows
+	`ALTER TABLE insights ADD COLUMN structured_json TEXT NOT NULL DEFAULT ''`,
+	// 3: call-quality metrics measured from the transcript as JSON, empty when not measurable
+	`ALTER TABLE insights ADD COLUMN metrics_json TEXT NOT NULL DEFAULT ''`,
+	// 4: detected language of the call, e.g. hi-Latn
+	`ALTER TABLE insights ADD COLUMN language TEXT NOT NULL DEFAULT ''`,
+	// 5: editable concern/resolution knowledge base, seeded with the original seven categories.
+	// List columns hold JSON arrays of strings.
+	`CREATE TABLE concern_categories (
+		code              TEXT    PRIMARY KEY,
+		label             TEXT    NOT NULL,
+		description       TEXT    NOT NULL DEFAULT '',
+		resolution        TEXT    NOT NULL DEFAULT '',
+		executive_actions TEXT    NOT NULL DEFAULT '[]',
+		seller_actions    TEXT    NOT NULL DEFAULT '[]',
+		escalation_team   TEXT    NOT NULL DEFAULT '',
+		keywords          TEXT    NOT NULL DEFAULT '[]',
+		position          INTEGER NOT NULL DEFAULT 0,
+		created_at        TEXT    NOT NULL,
+		updated_at        TEXT    NOT NULL
+	);
+	INSERT INTO concern_categories (code, label, description, resolution, executive_actions, seller_actions, escalation_team, keywords, position, created_at, updated_at) VALUES
+	('irrelevant_buyleads', 'Irrelevant Buyleads',
+		'Category/Location/Value issue: leads of the wrong product or location, low quantity or order value, or too few buyleads and enquiries',
+		'Executive must check category/location settings, add specific product categories or preferred locations, or suggest ''Filters''. For too few leads, guide the seller to high-quality content (images, specification, PDF, video) using the Product Quality score.',
+		'["Follow up tomorrow to check whether the leads are now relevant", "Upsell Opportunity: if leads are less, nudge for higher package/TrustSeal/STAR/LEADER"]',
+		'["Consume leads of the desired category and check whether they are now relevant"]',
+		'Category Team, when an upgraded seller still gets few leads',
+		'["irrelevant", "buylead", "buy lead", "wrong lead", "lead quality", "less lead", "low lead", "enquir", "relevant lead"]',
+		1, '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z'),
+	('domain_renew_change', 'Domain Renew/Change', 'Seller wants to renew the domain or change it',
+		'Guide seller to Godaddy/Provider login & upgrade from there, then reply on the mail thread shared by the executive. Changing domain is NOT recommended (SEO loss).',
+		'["Share the mail thread for the renewal"]', '["Renew the domain with the provider and reply on the mail thread"]', '',
+		'["domain"]', 2, '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z'),
+	('catalogue_update', 'Catalogue Update', 'Images, Specs, Score: add, replace or remove product images, specifications, description, price, PDF or videos',
+		'Guide on App/Desktop. Aim for Product Score 100 (Images, PDF, Video, Desc). When the seller cannot update it, collect the details over mail.',
+		'["Follow up until the details are updated and the ticket is closed"]', '["Update the product details, or share them over mail"]', '',
+		'["catalog", "catalogue", "image", "photo", "specification", "description", "product score", "pricing", "price", "pdf", "video"]',
+		3, '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z'),
+	('invoice_payment', 'Invoice/Payment', 'Invoice not visible, not reflected or needing changes, and payment issues',
+		'Verify on portal, guide user to invoice section.',
+		'["Escalate to Payments Team if system error and update the seller"]', '[]', 'Payments Team',
+		'["invoice", "payment", "billing", "refund"]', 4, '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z'),
+	('product_issue', 'Product/Tech Issue', 'App/Desktop: a section of the Desktop site or Android App cannot be opened or used',
+		'Reproduce with a screenshot and steps. Troubleshoot (Clear Cache, Incognito, Update App, login-logout). If fails, escalate to Product Team (Device specific).',
+		'["Escalate to the product team of the device and section"]', '["Share screenshots and the steps that reproduce the issue"]', 'Product Team of the device and section',
+		'["app ", "android", "desktop", "login", "not working", "crash", "cache", "technical", "bug", "error"]',
+		5, '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z'),
+	('account_update', 'Account Update', 'Contact, Address, Password: name, CEO or contact person, phone numbers, address or password',
+		'Update on call if allowed. If not, ask for proof via mail and escalate.',
+		'["Escalate the details the seller cannot update to the relevant team"]', '["Share the detail with proof over mail"]', '',
+		'["account", "contact person", "address", "password", "phone number", "mobile number", "ceo name"]',
+		6, '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z'),
+	('settings', 'Settings', 'PNS, Alerts, Whatsapp: notifications, PNS call settings, buylead location and category preferences',
+		'Configure ''Preferred/Not Preferred'' locations or categories. Link/Unlink PNS numbers.',
+		'[]', '[]', '',
+		'["setting", "pns", "notification", "whatsapp", "sms", "preference", "not preferred"]',
+		7, '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z');`,
+	// 6: resolution compliance check of the call as JSON, empty when the call was not checked
+	`ALTER TABLE insights ADD COLUMN compliance_json TEXT NOT NULL DEFAULT ''`,
+	// 7: recording or transcript URL of the call, empty for inline transcripts and uploads
+	`ALTER TABLE insights ADD COLUMN source_url TEXT NOT NULL DEFAULT ''`,
+	// 8: action items tracked from the next steps of call-level insights
+	`CREATE TABLE action_items (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		insight_id   INTEGER NOT NULL REFERENCES insights (id),
+		glid         INTEGER NOT NULL,
+		executive_id TEXT    NOT NULL DEFAULT '',
+		owner        TEXT    NOT NULL DEFAULT '',
+		description  TEXT    NOT NULL,
+		call_date    TEXT    NOT NULL DEFAULT '',
+		due_date     TEXT    NOT NULL DEFAULT '',
+		status       TEXT    NOT NULL DEFAULT 'open',
+		completed_at TEXT    NOT NULL DEFAULT '',
+		created_at   TEXT    NOT NULL,
+		updated_at   TEXT    NOT NULL
+	);
+	CREATE INDEX idx_action_items_executive_status ON action_items (executive_id, status, due_date);
+	CREATE INDEX idx_action_items_glid ON action_items (glid, due_date);`,
+	// 9: alerts of stored insights routed to notifiers, with their delivery attempts
+	`CREATE TABLE alert_deliveries (
+		id              INTEGER PRIMARY KEY AUTOINCREMENT,
+		insight_id      INTEGER NOT NULL REFERENCES insights (id),
+		glid            INTEGER NOT NULL,
+		executive_id    TEXT    NOT NULL DEFAULT '',
+		customer_type   TEXT    NOT NULL DEFAULT '',
+		customer_city   TEXT    NOT NULL DEFAULT '',
+		call_date       TEXT    NOT NULL DEFAULT '',
+		rule            TEXT    NOT NULL,
+		notifier        TEXT    NOT NULL,
+		alert_type      TEXT    NOT NULL,
+		severity        TEXT    NOT NULL DEFAULT '',
+		description     TEXT    NOT NULL DEFAULT '',
+		dedupe_key      TEXT    NOT NULL,
+		status          TEXT    NOT NULL,
+		attempts        INTEGER NOT NULL DEFAULT 0,
+		last_error      TEXT    NOT NULL DEFAULT '',
+		next_attempt_at TEXT    NOT NULL DEFAULT '',
+		sent_at         TEXT    NOT NULL DEFAULT '',
+		created_at      TEXT    NOT NULL,
+		updated_at      TEXT    NOT NULL
+	);
+	CREATE INDEX idx_alert_deliveries_status ON alert_deliveries (status, next_attempt_at);
+	CREATE INDEX idx_alert_deliveries_dedupe ON alert_deliveries (dedupe_key, created_at);`,
+}
+
+// migrate brings the schema up to date
+func migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for index := current; index < len(migrations); index++ {
+		version := index + 1
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[index]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", version, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package insightStore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const insightColumns = `id, glid, executive_id, customer_type, customer_city, call_index, call_type, call_date,
+	insight_type, concerns, resolution, next_steps, alert, sentiment, key_points, model, prompt_version, structured_json, metrics_json, language, compliance_json, source_url, created_at`
+
+// SQLiteRepository is the Repository backed by a local SQLite file
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens (or creates) the database at path and applies pending migrations
+func OpenSQLite(ctx context.Context, path string) (*SQLiteRepository, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open insight store: %w", err)
+	}
+	// SQLite allows one writer at a time, a single connection avoids SQLITE_BUSY between workers
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteRepository{db: db}, nil
+}
+
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+// DB exposes the connection to other stores sharing the same file
+func (r *SQLiteRepository) DB() *sql.DB {
+	return r.db
+}
+
+func (r *SQLiteRepository) SaveInsights(ctx context.Context, insights []StoredInsight) ([]int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO insights (glid, executive_id, customer_type, customer_city, call_index, call_type, call_date,
+		insight_type, concerns, resolution, next_steps, alert, sentiment, key_points, model, prompt_version, structured_json, metrics_json, language, compliance_json,
+		source_url, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	ids := make([]int64, 0, len(insights))
+	now := time.Now().UTC()
+	for _, insight := range insights {
+		if insight.CreatedAt.IsZero() {
+			insight.CreatedAt = now
+		}
+		result, execErr := stmt.ExecContext(ctx,
+			insight.Glid, insight.ExecutiveID, insight.CustomerType, insight.CustomerCity, insight.CallIndex, insight.CallType, insight.CallDate,
+			insight.InsightType, insight.Concerns, insight.Resolution, insight.NextSteps, insight.Alert, insight.Sentiment, insight.KeyPoints,
+			insight.Model, insight.PromptVersion, insight.StructuredJSON, insight.MetricsJSON, insight.Language, insight.ComplianceJSON,
+			insight.SourceURL, insight.CreatedAt.UTC().Format(time.RFC3339Nano),
+		)
+		if execErr != nil {
+			return nil, fmt.Errorf("failed to insert insight: %w", execErr)
+		}
+		id, idErr := result.LastInsertId()
+		if idErr != nil {
+			return nil, idErr
+		}
+		ids = append(ids, id)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ListInsights returns matching insights, most recent call first
+func (r *SQLiteRepository) ListInsights(ctx context.Context, filter InsightFilter) ([]StoredInsight, error) {
+	where, args := filter.whereClause()
+	query := `SELECT ` + insightColumns + ` FROM insights` + where + ` ORDER BY call_date DESC, id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query insights: %w", err)
+	}
+	defer rows.Close()
+
+	insights := []StoredInsight{}
+	for rows.Next() {
+		insight, scanErr := scanInsight(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		insights = append(insights, insight)
+	}
+	return insights, rows.Err()
+}
+
+func (filter InsightFilter) whereClause() (string, []any) {
+	var conditions []string
+	var args []any
+	if len(filter.Glids) > 0 {
+		conditions = append(conditions, "glid IN ("+placeholders(len(filter.Glids))+")")
+		for _, glid := range filter.Glids {
+			args = append(args, glid)
+		}
+	}
+	if filter.ExecutiveID != "" {
+		conditions = append(conditions, "executive_id = ?")
+		args = append(args, filter.ExecutiveID)
+	}
+	if filter.CustomerType != "" {
+		conditions = append(conditions, "LOWER(customer_type) = LOWER(?)")
+		args = append(args, filter.CustomerType)
+	}
+	if filter.CustomerCity != "" {
+		conditions = append(conditions, "LOWER(customer_city) = LOWER(?)")
+		args = append(args, filter.CustomerCity)
+	}
+	if filter.InsightType != "" {
+		conditions = append(conditions, "insight_type = ?")
+		args = append(args, filter.InsightType)
+	}
+	if filter.FromDate != "" {
+		conditions = append(conditions, "call_date >= ?")
+		args = append(args, filter.FromDate)
+	}
+	if filter.ToDate != "" {
+		conditions = append(conditions, "call_date <= ?")
+		args = append(args, filter.ToDate)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInsight(row rowScanner) (StoredInsight, error) {
+	var insight StoredInsight
+	var createdAt string
+	err := row.Scan(&insight.ID, &insight.Glid, &insight.ExecutiveID, &insight.CustomerType, &insight.CustomerCity, &insight.CallIndex,
+		&insight.CallType, &insight.CallDate, &insight.InsightType, &insight.Concerns, &insight.Resolution, &insight.NextSteps,
+		&insight.Alert, &insight.Sentiment, &insight.KeyPoints, &insight.Model, &insight.PromptVersion, &insight.StructuredJSON, &insight.MetricsJSON, &insight.Language,
+		&insight.ComplianceJSON, &insight.SourceURL, &createdAt)
+	if err != nil {
+		return insight, fmt.Errorf("failed to scan insight: %w", err)
+	}
+	insight.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+	return insight, nil
+}
@@ -0,0 +1,164 @@
+package insightStore
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T, path string) *SQLiteRepository {
+	t.Helper()
+	store, err := OpenSQLite(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func appliedVersions(t *testing.T, store *SQLiteRepository) []int {
+	t.Helper()
+	rows, err := store.DB().Query(`SELECT version FROM schema_migrations ORDER BY applied_at, version`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var versions []int
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+func TestMigrationsApplyInOrderOnce(t *testing.T) {
+	previous := migrations
+	t.Cleanup(func() { migrations = previous })
+	// the second migration only works after the first one
+	migrations = append(append([]string{}, previous...),
+		`CREATE TABLE migration_probe (id INTEGER PRIMARY KEY)`,
+		`ALTER TABLE migration_probe ADD COLUMN note TEXT NOT NULL DEFAULT ''`,
+	)
+	path := filepath.Join(t.TempDir(), "insights.db")
+
+	store := openTestStore(t, path)
+	want := make([]int, len(migrations))
+	for i := range want {
+		want[i] = i + 1
+	}
+	if got := appliedVersions(t, store); !reflect.DeepEqual(got, want) {
+		t.Fatalf("applied versions = %v, want %v", got, want)
+	}
+	if _, err := store.DB().Exec(`INSERT INTO migration_probe (note) VALUES ('kept')`); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := migrate(ctx, store.DB()); err != nil {
+		t.Fatalf("rerunning the migrations: %v", err)
+	}
+	if got := appliedVersions(t, store); !reflect.DeepEqual(got, want) {
+		t.Errorf("after a rerun applied versions = %v, want %v", got, want)
+	}
+	store.Close()
+
+	migrations = append(migrations, `CREATE INDEX idx_migration_probe_note ON migration_probe (note)`)
+	reopened := openTestStore(t, path)
+	if got := appliedVersions(t, reopened); len(got) != len(want)+1 || got[len(got)-1] != len(migrations) {
+		t.Errorf("reopening applied versions %v, want only the new migration added", got)
+	}
+	var note string
+	if err := reopened.DB().QueryRow(`SELECT note FROM migration_probe`).Scan(&note); err != nil || note != "kept" {
+		t.Errorf("probe row = %q, %v, want the row written before reopening", note, err)
+	}
+}
+
+func TestSaveInsightsRoundTrip(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "insights.db"))
+	ctx := context.Background()
+	created := time.Date(2026, 10, 17, 9, 30, 0, 123, time.UTC)
+	saved := []StoredInsight{
+		{
+			Glid: 42, ExecutiveID: "E1", CustomerType: "Existing", CustomerCity: "Pune", CallIndex: 1, CallType: "PNS",
+			CallDate: "2026-10-16", InsightType: "call_1", Concerns: "Lead quality", Resolution: "Explained filters",
+			NextSteps: "Call back", Alert: "None", Sentiment: "Neutral", KeyPoints: "leads", Model: "fake",
+			PromptVersion: "v1", CreatedAt: created,
+		},
+		{Glid: 42, CallIndex: 2, CallDate: "2026-10-17", InsightType: "call_2"},
+	}
+
+	ids, err := store.SaveInsights(ctx, saved)
+	if err != nil {
+		t.Fatalf("SaveInsights: %v", err)
+	}
+	if len(ids) != 2 || ids[0] >= ids[1] {
+		t.Fatalf("ids = %v, want two increasing ids", ids)
+	}
+	loaded, err := store.ListInsights(ctx, InsightFilter{})
+	if err != nil {
+		t.Fatalf("ListInsights: %v", err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("loaded %d insights, want 2", len(loaded))
+	}
+	// most recent call first
+	first, second := loaded[1], loaded[0]
+	want := saved[0]
+	want.ID = ids[0]
+	if !reflect.DeepEqual(first, want) {
+		t.Errorf("loaded %+v\nwant   %+v", first, want)
+	}
+	if second.ID != ids[1] || second.CreatedAt.IsZero() {
+		t.Errorf("second insight = %+v, want its id and a creation time", second)
+	}
+}
+
+func TestInsightFilterWhereClause(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "insights.db"))
+	ctx := context.Background()
+	if _, err := store.SaveInsights(ctx, []StoredInsight{
+		{Glid: 1, ExecutiveID: "E1", CustomerType: "Existing", CustomerCity: "New Delhi", CallIndex: 1, CallDate: "2026-10-01", InsightType: "call_1"},
+		{Glid: 2, ExecutiveID: "E2", CustomerType: "New", CustomerCity: "Pune", CallIndex: 1, CallDate: "2026-10-05", InsightType: "final"},
+		{Glid: 3, ExecutiveID: "E1", CustomerType: "existing", CustomerCity: "new delhi", CallIndex: 1, CallDate: "2026-10-09", InsightType: "final"},
+		{Glid: 1, ExecutiveID: "E1", CustomerType: "Existing", CustomerCity: "Mumbai", CallIndex: 2, CallDate: "2026-10-12", InsightType: "call_2"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		filter InsightFilter
+		want   []string // call dates, most recent first
+	}{
+		{"everything", InsightFilter{}, []string{"2026-10-12", "2026-10-09", "2026-10-05", "2026-10-01"}},
+		{"one glid", InsightFilter{Glids: []int{1}}, []string{"2026-10-12", "2026-10-01"}},
+		{"glid list", InsightFilter{Glids: []int{2, 3}}, []string{"2026-10-09", "2026-10-05"}},
+		{"executive", InsightFilter{ExecutiveID: "E2"}, []string{"2026-10-05"}},
+		{"inclusive date range", InsightFilter{FromDate: "2026-10-05", ToDate: "2026-10-09"}, []string{"2026-10-09", "2026-10-05"}},
+		{"from date only", InsightFilter{FromDate: "2026-10-10"}, []string{"2026-10-12"}},
+		{"glids and dates", InsightFilter{Glids: []int{1, 3}, ToDate: "2026-10-09"}, []string{"2026-10-09", "2026-10-01"}},
+		{"customer type in any case", InsightFilter{CustomerType: "EXISTING"}, []string{"2026-10-12", "2026-10-09", "2026-10-01"}},
+		{"city in any case", InsightFilter{CustomerCity: "New DELHI"}, []string{"2026-10-09", "2026-10-01"}},
+		{"city is matched whole", InsightFilter{CustomerCity: "Delhi"}, nil},
+		{"insight type", InsightFilter{InsightType: "final"}, []string{"2026-10-09", "2026-10-05"}},
+		{"every filter", InsightFilter{Glids: []int{1, 3}, ExecutiveID: "E1", CustomerType: "existing", CustomerCity: "NEW DELHI", InsightType: "final", FromDate: "2026-10-02"}, []string{"2026-10-09"}},
+		{"limit", InsightFilter{Limit: 1}, []string{"2026-10-12"}},
+		{"no match", InsightFilter{Glids: []int{9}}, nil},
+	} {
+		loaded, err := store.ListInsights(ctx, tc.filter)
+		if err != nil {
+			t.Fatalf("%s: ListInsights: %v", tc.name, err)
+		}
+		var dates []string
+		for _, insight := range loaded {
+			dates = append(dates, insight.CallDate)
+		}
+		if !reflect.DeepEqual(dates, tc.want) {
+			t.Errorf("%s: call dates = %v, want %v", tc.name, dates, tc.want)
+		}
+	}
+}
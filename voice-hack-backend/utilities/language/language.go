@@ -0,0 +1,176 @@
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Language codes returned by Detect and accepted as output languages
+const (
+	English   = "en"
+	Hindi     = "hi"
+	Hinglish  = "hi-Latn" // Hindi written in Latin script, usually mixed with English
+	Marathi   = "mr"
+	Bengali   = "bn"
+	Gujarati  = "gu"
+	Punjabi   = "pa"
+	Odia      = "or"
+	Tamil     = "ta"
+	Telugu    = "te"
+	Kannada   = "kn"
+	Malayalam = "ml"
+	Unknown   = "und"
+)
+
+var names = map[string]string{
+	English: "English", Hindi: "Hindi", Hinglish: "Hinglish", Marathi: "Marathi", Bengali: "Bengali",
+	Gujarati: "Gujarati", Punjabi: "Punjabi", Odia: "Odia", Tamil: "Tamil", Telugu: "Telugu",
+	Kannada: "Kannada", Malayalam: "Malayalam", Unknown: "Unknown",
+}
+
+// Codes lists the supported language codes
+func Codes() []string {
+	return []string{English, Hindi, Hinglish, Marathi, Bengali, Gujarati, Punjabi, Odia, Tamil, Telugu, Kannada, Malayalam}
+}
+
+// IsSupported reports whether code is one of Codes, case-insensitively
+func IsSupported(code string) bool {
+	for _, candidate := range Codes() {
+		if strings.EqualFold(candidate, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// Name returns the English name of a language code, or the code itself when unknown
+func Name(code string) string {
+	for candidate, name := range names {
+		if strings.EqualFold(candidate, code) {
+			return name
+		}
+	}
+	return code
+}
+
+// Detection is the language detected for one transcript
+type Detection struct {
+	Code       string  `json:"code"`
+	Name       string  `json:"name"`
+	Confidence float64 `json:"confidence"` // share of the evidence (letters or words) behind Code, 0 to 1
+}
+
+// scriptLanguages maps the Indic scripts to the language usually written in them
+var scriptLanguages = []struct {
+	Table *unicode.RangeTable
+	Code  string
+}{
+	{unicode.Devanagari, Hindi},
+	{unicode.Bengali, Bengali},
+	{unicode.Gurmukhi, Punjabi},
+	{unicode.Gujarati, Gujarati},
+	{unicode.Oriya, Odia},
+	{unicode.Tamil, Tamil},
+	{unicode.Telugu, Telugu},
+	{unicode.Kannada, Kannada},
+	{unicode.Malayalam, Malayalam},
+}
+
+// hinglishWords are common romanized Hindi words that are not English words
+var hinglishWords = map[string]bool{
+	"hai": true, "hain": true, "nahi": true, "nahin": true, "kya": true, "aap": true, "aapka": true, "aapke": true,
+	"mein": true, "haan": true, "ji": true, "bhi": true, "karo": true, "kar": true, "karna": true,
+	"raha": true, "rahe": true, "rahi": true, "hoon": true, "hun": true, "ka": true, "ki": true, "ke": true,
+	"ko": true, "se": true, "yeh": true, "ye": true, "woh": true, "wo": true, "bol": true, "bolo": true,
+	"theek": true, "thik": true, "accha": true, "acha": true, "abhi": true, "kuch": true, "lekin": true,
+	"matlab": true, "bhai": true, "kaise": true, "kyun": true, "kyon": true, "sahi": true, "batao": true,
+	"dekhiye": true, "dijiye": true, "kijiye": true, "hoga": true, "hogi": true, "tha": true, "thi": true,
+	"mera": true, "meri": true, "mere": true, "humara": true, "hamara": true, "aur": true, "koi": true,
+}
+
+// marathiWords tell Marathi apart from Hindi, both being written in Devanagari
+var marathiWords = map[string]bool{"आहे": true, "नाही": true, "आणि": true, "मला": true, "तुम्ही": true, "आम्ही": true, "काय": true, "होते": true}
+
+// Detect guesses the language of text from its script, and for Latin script from romanized Hindi words.
+// Text with more than a third of its letters in an Indic script is detected as that script's language.
+func Detect(text string) Detection {
+	latin := 0
+	indic := make([]int, len(scriptLanguages))
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Mc, r) {
+			continue
+		}
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for i, script := range scriptLanguages {
+			if unicode.Is(script.Table, r) {
+				indic[i]++
+				break
+			}
+		}
+	}
+
+	best, bestCount, indicTotal := 0, 0, 0
+	for i, count := range indic {
+		indicTotal += count
+		if count > bestCount {
+			best, bestCount = i, count
+		}
+	}
+	letters := latin + indicTotal
+	if letters == 0 {
+		return detection(Unknown, 0)
+	}
+
+	if float64(indicTotal)/float64(letters) > 1.0/3 {
+		code := scriptLanguages[best].Code
+		if code == Hindi && countWords(splitWords(text), marathiWords) >= 2 {
+			code = Marathi
+		}
+		return detection(code, float64(bestCount)/float64(letters))
+	}
+
+	words := splitWords(text)
+	if len(words) == 0 {
+		return detection(Unknown, 0)
+	}
+	share := float64(countWords(words, hinglishWords)) / float64(len(words))
+	// Hindi function words make up a large share of Hinglish speech, a few percent is enough
+	if share >= 0.08 {
+		return detection(Hinglish, clamp(share*4))
+	}
+	return detection(English, clamp(1-share*4))
+}
+
+func detection(code string, confidence float64) Detection {
+	return Detection{Code: code, Name: Name(code), Confidence: float64(int(confidence*100)) / 100}
+}
+
+// splitWords lowercases text and splits it into words, keeping Indic vowel signs inside the words
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Mc, r)
+	})
+}
+
+func countWords(words []string, known map[string]bool) int {
+	count := 0
+	for _, word := range words {
+		if known[word] {
+			count++
+		}
+	}
+	return count
+}
+
+func clamp(value float64) float64 {
+	if value > 1 {
+		return 1
+	}
+	if value < 0 {
+		return 0
+	}
+	return value
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeProvider returns scripted responses and records every request it gets.
+// Push responses before the call, or set Handler to compute them. It is not registered by
+// default; tests add it with RegisterProvider so requests cannot select it in production.
+type FakeProvider struct {
+	mu        sync.Mutex
+	responses []string
+	requests  []CompletionRequest
+	Handler   func(req CompletionRequest) (string, error)
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+func (p *FakeProvider) Name() string {
+	return ProviderFake
+}
+
+// Push queues responses returned in order by the next Complete calls
+func (p *FakeProvider) Push(responses ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.responses = append(p.responses, responses...)
+}
+
+// Requests returns a copy of the requests received so far
+func (p *FakeProvider) Requests() []CompletionRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]CompletionRequest{}, p.requests...)
+}
+
+// Reset drops queued responses and recorded requests
+func (p *FakeProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.responses = nil
+	p.requests = nil
+}
+
+func (p *FakeProvider) Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	handler := p.Handler
+	var content string
+	scripted := len(p.responses) > 0
+	if scripted {
+		content = p.responses[0]
+		p.responses = p.responses[1:]
+	}
+	p.mu.Unlock()
+
+	if !scripted {
+		if handler == nil {
+			return CompletionResponse{}, fmt.Errorf("fake LLM provider has no scripted response")
+		}
+		var err error
+		if content, err = handler(req); err != nil {
+			return CompletionResponse{}, err
+		}
+	}
+	return CompletionResponse{Content: content, Provider: p.Name(), Model: "fake"}, nil
+}
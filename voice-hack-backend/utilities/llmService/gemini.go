@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"voice-hack-backend/config"
+	"voice-hack-backend/utilities/genaiService"
+
+	"google.golang.org/genai"
+)
+
+// GeminiProvider calls Gemini directly through the shared genai client
+type GeminiProvider struct {
+	Model string
+}
+
+func NewGeminiProvider(cfg config.GeminiConfig) *GeminiProvider {
+	return &GeminiProvider{Model: cfg.Model}
+}
+
+func (p *GeminiProvider) Name() string {
+	return ProviderGemini
+}
+
+func (p *GeminiProvider) Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
+	// Get GenAI client
+	client, clientErr := genaiService.GetClient()
+	if clientErr != nil {
+		return CompletionResponse{}, clientErr
+	}
+
+	// Check if model is available
+	model := p.Model
+	if req.Model != "" {
+		model = req.Model
+	}
+	if isModelAvailableErr := genaiService.IsModelAvailable(ctx, client, model); isModelAvailableErr != nil {
+		return CompletionResponse{}, isModelAvailableErr
+	}
+
+	// Prepare conversation contents, Gemini needs at least one user turn
+	contents := []*genai.Content{}
+	userPrompt := req.UserPrompt
+	if userPrompt == "" && len(req.Messages) == 0 {
+		userPrompt = req.SystemPrompt
+	}
+	if userPrompt != "" {
+		contents = append(contents, genai.NewContentFromText(userPrompt, genai.RoleUser))
+	}
+	for _, message := range req.Messages {
+		role := genai.Role(genai.RoleUser)
+		if message.Role == "assistant" {
+			role = genai.RoleModel
+		}
+		contents = append(contents, genai.NewContentFromText(message.Content, role))
+	}
+
+	contentGenerateConfig := genai.GenerateContentConfig{
+		Tools: nil, //[]*genai.Tool{{GoogleSearch: &genai.GoogleSearch{}}},
+	}
+	if req.SystemPrompt != "" && userPrompt != req.SystemPrompt {
+		contentGenerateConfig.SystemInstruction = genai.NewContentFromText(req.SystemPrompt, genai.RoleUser)
+	}
+	if req.JSONMode || req.Schema != nil {
+		contentGenerateConfig.ResponseMIMEType = "application/json"
+	}
+	if req.Schema != nil {
+		contentGenerateConfig.ResponseSchema = toGenaiSchema(req.Schema)
+	}
+
+	// Generate content
+	modelResponse, respErr := client.Models.GenerateContent(ctx, model, contents, &contentGenerateConfig)
+	if respErr != nil {
+		return CompletionResponse{}, respErr
+	}
+	return CompletionResponse{Content: modelResponse.Text(), Provider: p.Name(), Model: model}, nil
+}
+
+// toGenaiSchema converts the provider-neutral schema into Gemini's OpenAPI subset
+func toGenaiSchema(schema *Schema) *genai.Schema {
+	if schema == nil {
+		return nil
+	}
+	converted := &genai.Schema{
+		Type:        genai.Type(strings.ToUpper(schema.Type)),
+		Description: schema.Description,
+		Enum:        schema.Enum,
+		Required:    schema.Required,
+		Items:       toGenaiSchema(schema.Items),
+	}
+	if len(schema.Enum) > 0 {
+		converted.Format = "enum"
+	}
+	if len(schema.Properties) > 0 {
+		converted.Properties = make(map[string]*genai.Schema, len(schema.Properties))
+		for key, property := range schema.Properties {
+			converted.Properties[key] = toGenaiSchema(property)
+		}
+	}
+	return converted
+}
@@ -1,74 +1,124 @@
 package llm
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
-	globalconstant "voice-hack-backend/globalConstant"
+	"voice-hack-backend/config"
 	"voice-hack-backend/utilities/httpRequest"
 )
 
-// Function using MakeHttpCall
-func GenerateInsightsViaLLM(userQuery string, systemPrompt string) (string, error) {
+// GatewayProvider talks to an OpenAI-compatible chat completions endpoint
+type GatewayProvider struct {
+	URL         string
+	APIKey      string
+	Model       string
+	Temperature float64
+	Timeout     time.Duration
+}
+
+func NewGatewayProvider(cfg config.LLMConfig) *GatewayProvider {
+	return &GatewayProvider{
+		URL:         cfg.APIURL,
+		APIKey:      cfg.APIKey,
+		Model:       cfg.Model,
+		Temperature: cfg.Temperature,
+		Timeout:     cfg.Timeout,
+	}
+}
+
+func (p *GatewayProvider) Name() string {
+	return ProviderGateway
+}
+
+func (p *GatewayProvider) Complete(ctx context.Context, completionReq CompletionRequest) (CompletionResponse, error) {
+	model := p.Model
+	if completionReq.Model != "" {
+		model = completionReq.Model
+	}
+
 	// Prepare request body
 	reqBody := map[string]any{
-		"model": globalconstant.LLM_MODEL,
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": userQuery,
-			},
-			{
-				"role":    "system",
-				"content": systemPrompt,
-			},
-		},
-		"temperature": 0.7,
+		"model":       model,
+		"messages":    BuildMessages(completionReq),
+		"temperature": p.Temperature,
 		// "max_tokens":  500,
 	}
+	if completionReq.Schema != nil {
+		schemaName := completionReq.SchemaName
+		if schemaName == "" {
+			schemaName = "response"
+		}
+		reqBody["response_format"] = map[string]any{
+			"type": "json_schema",
+			"json_schema": map[string]any{
+				"name":   schemaName,
+				"schema": completionReq.Schema,
+			},
+		}
+	} else if completionReq.JSONMode {
+		reqBody["response_format"] = map[string]any{"type": "json_object"}
+	}
 
 	// Prepare HttpRequest
 	req := httpRequest.HttpRequest{
 		Method: http.MethodPost,
-		URL:    globalconstant.LLM_API_URL,
+		URL:    p.URL,
 		Headers: map[string]any{
-			"Authorization": "Bearer " + globalconstant.LLM_API_KEY,
+			"Authorization": "Bearer " + p.APIKey,
 			"Content-Type":  "application/json",
 		},
 		Body:    reqBody,
-		Timeout: 30 * time.Second,
+		Timeout: p.Timeout,
 	}
 
 	// Call LLM Gateway via MakeHttpCall
 	resp := httpRequest.MakeHttpCall(req)
 	if resp.Err != nil {
-		return "", fmt.Errorf("LLM Gateway call failed: %v", resp.Err)
+		return CompletionResponse{}, fmt.Errorf("LLM Gateway call failed: %v", resp.Err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("LLM Gateway returned status %d", resp.StatusCode)
+		return CompletionResponse{}, fmt.Errorf("LLM Gateway returned status %d", resp.StatusCode)
 	}
 
 	// Extract response text
 	choices, ok := resp.Body["choices"].([]any)
 	if !ok || len(choices) == 0 {
-		return "", fmt.Errorf("no choices returned from LLM")
+		return CompletionResponse{}, fmt.Errorf("no choices returned from LLM")
 	}
 
 	firstChoice, ok := choices[0].(map[string]any)
 	if !ok {
-		return "", fmt.Errorf("invalid choice structure")
+		return CompletionResponse{}, fmt.Errorf("invalid choice structure")
 	}
 
 	message, ok := firstChoice["message"].(map[string]any)
 	if !ok {
-		return "", fmt.Errorf("invalid message structure")
+		return CompletionResponse{}, fmt.Errorf("invalid message structure")
 	}
 
 	content, ok := message["content"].(string)
 	if !ok {
-		return "", fmt.Errorf("no content in message")
+		return CompletionResponse{}, fmt.Errorf("no content in message")
 	}
 
-	return content, nil
+	return CompletionResponse{Content: content, Provider: p.Name(), Model: model}, nil
+}
+
+// GenerateInsightsViaLLM sends a plain system/user prompt pair to the gateway provider
+func GenerateInsightsViaLLM(userQuery string, systemPrompt string) (string, error) {
+	provider, err := GetProvider(ProviderGateway)
+	if err != nil {
+		return "", err
+	}
+	resp, err := provider.Complete(context.Background(), CompletionRequest{
+		SystemPrompt: systemPrompt,
+		UserPrompt:   userQuery,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
 }
@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"voice-hack-backend/config"
+)
+
+// Provider names understood by GetProvider
+const (
+	ProviderGateway = "gateway" // OpenAI-compatible chat completions gateway
+	ProviderGemini  = "gemini"  // Gemini via genaiService
+	ProviderFake    = "fake"    // scripted provider, only registered by tests
+)
+
+// Message is one chat turn sent to a provider
+type Message struct {
+	Role    string `json:"role"` // system, user or assistant
+	Content string `json:"content"`
+}
+
+// Schema is a provider-neutral JSON schema used to constrain the model output.
+// Type uses JSON schema names: object, array, string, integer, number, boolean.
+type Schema struct {
+	Type        string             `json:"type"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+}
+
+// CompletionRequest is the input for a single chat completion
+type CompletionRequest struct {
+	Model        string    // Optional override of the provider's default model
+	SystemPrompt string    // System instructions
+	UserPrompt   string    // User message
+	Messages     []Message // Optional extra turns sent after the user message
+	JSONMode     bool      // Ask the provider to return a JSON object
+	Schema       *Schema   // Optional response schema, implies JSONMode
+	SchemaName   string    // Name reported to providers that need one
+}
+
+// CompletionResponse is the raw text returned by a provider
+type CompletionResponse struct {
+	Content  string
+	Provider string
+	Model    string
+}
+
+// Provider is implemented by every LLM backend
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error)
+}
+
+var (
+	providersMu     sync.RWMutex
+	providers       = map[string]Provider{}
+	defaultProvider = ProviderGateway
+)
+
+func init() {
+	Configure(config.Get())
+}
+
+// Configure (re)builds the gateway and Gemini providers from cfg and selects the default provider
+func Configure(cfg *config.Config) {
+	RegisterProvider(NewGatewayProvider(cfg.LLM))
+	RegisterProvider(NewGeminiProvider(cfg.Gemini))
+	SetDefaultProvider(cfg.LLM.Provider)
+}
+
+// RegisterProvider adds or replaces a provider under its Name()
+func RegisterProvider(provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[provider.Name()] = provider
+}
+
+// SetDefaultProvider changes the provider used when a request does not pick one
+func SetDefaultProvider(name string) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	defaultProvider = name
+}
+
+// GetProvider returns the named provider, or the default one when name is empty
+func GetProvider(name string) (Provider, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	if name == "" {
+		name = defaultProvider
+	}
+	provider, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown LLM provider %q", name)
+	}
+	return provider, nil
+}
+
+// BuildMessages flattens a request into the ordered chat turns
+func BuildMessages(req CompletionRequest) []Message {
+	messages := []Message{}
+	if req.SystemPrompt != "" {
+		messages = append(messages, Message{Role: "system", Content: req.SystemPrompt})
+	}
+	if req.UserPrompt != "" {
+		messages = append(messages, Message{Role: "user", Content: req.UserPrompt})
+	}
+	return append(messages, req.Messages...)
+}
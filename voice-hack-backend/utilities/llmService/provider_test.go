@@ -0,0 +1,211 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"voice-hack-backend/config"
+)
+
+func TestFakeProviderIsNotRegisteredByDefault(t *testing.T) {
+	defaults := config.Defaults()
+	Configure(&defaults)
+
+	if _, err := GetProvider(ProviderFake); err == nil {
+		t.Fatal("the fake provider is registered without a test adding it")
+	}
+	provider, err := GetProvider("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.Name() != defaults.LLM.Provider {
+		t.Errorf("default provider is %q, want %q", provider.Name(), defaults.LLM.Provider)
+	}
+	if _, err := GetProvider("unknown"); err == nil || !strings.Contains(err.Error(), `"unknown"`) {
+		t.Errorf("GetProvider(unknown) error = %v", err)
+	}
+}
+
+func TestRegisteredFakeProviderBecomesDefault(t *testing.T) {
+	fake := NewFakeProvider()
+	RegisterProvider(fake)
+	SetDefaultProvider(ProviderFake)
+	t.Cleanup(func() {
+		providersMu.Lock()
+		delete(providers, ProviderFake)
+		providersMu.Unlock()
+		defaults := config.Defaults()
+		Configure(&defaults)
+	})
+
+	provider, err := GetProvider("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider != fake {
+		t.Fatalf("default provider is %T, want the registered fake", provider)
+	}
+}
+
+func TestFakeProviderReturnsScriptedResponsesInOrder(t *testing.T) {
+	fake := NewFakeProvider()
+	fake.Push("first", "second")
+	fake.Handler = func(req CompletionRequest) (string, error) {
+		return "handled " + req.UserPrompt, nil
+	}
+
+	var got []string
+	for _, prompt := range []string{"a", "b", "c"} {
+		resp, err := fake.Complete(context.Background(), CompletionRequest{UserPrompt: prompt})
+		if err != nil {
+			t.Fatalf("Complete(%q): %v", prompt, err)
+		}
+		if resp.Provider != ProviderFake {
+			t.Errorf("response provider = %q", resp.Provider)
+		}
+		got = append(got, resp.Content)
+	}
+	if strings.Join(got, ",") != "first,second,handled c" {
+		t.Errorf("responses = %v, want the scripted ones then the handler", got)
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 3 || requests[0].UserPrompt != "a" || requests[2].UserPrompt != "c" {
+		t.Errorf("recorded requests = %+v", requests)
+	}
+	fake.Reset()
+	if len(fake.Requests()) != 0 {
+		t.Error("Reset kept the recorded requests")
+	}
+}
+
+func TestFakeProviderFailsWithoutScript(t *testing.T) {
+	fake := NewFakeProvider()
+	if _, err := fake.Complete(context.Background(), CompletionRequest{}); err == nil {
+		t.Fatal("Complete succeeded with nothing scripted")
+	}
+
+	handlerErr := errors.New("scripted failure")
+	fake.Handler = func(CompletionRequest) (string, error) { return "", handlerErr }
+	if _, err := fake.Complete(context.Background(), CompletionRequest{}); !errors.Is(err, handlerErr) {
+		t.Errorf("Complete error = %v, want the handler error", err)
+	}
+}
+
+func TestBuildMessagesOrder(t *testing.T) {
+	messages := BuildMessages(CompletionRequest{
+		SystemPrompt: "system",
+		UserPrompt:   "user",
+		Messages:     []Message{{Role: "assistant", Content: "earlier answer"}},
+	})
+	var roles []string
+	for _, message := range messages {
+		roles = append(roles, message.Role)
+	}
+	if strings.Join(roles, ",") != "system,user,assistant" {
+		t.Errorf("roles = %v", roles)
+	}
+	if got := BuildMessages(CompletionRequest{UserPrompt: "only"}); len(got) != 1 || got[0].Role != "user" {
+		t.Errorf("BuildMessages without a system prompt = %+v", got)
+	}
+}
+
+// startGateway serves a chat completion whose content is reply, or an empty object when status is
+// not 200, and records the body and headers of every request
+func startGateway(t *testing.T, status int, reply string) (*GatewayProvider, <-chan map[string]any, <-chan http.Header) {
+	t.Helper()
+	bodies := make(chan map[string]any, 10)
+	headers := make(chan http.Header, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		json.Unmarshal(raw, &body)
+		bodies <- body
+		headers <- r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			json.NewEncoder(w).Encode(map[string]any{
+				"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": reply}}},
+			})
+		} else {
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	cfg := config.Defaults().LLM
+	cfg.APIURL, cfg.APIKey, cfg.Model = server.URL, "secret", "default-model"
+	return NewGatewayProvider(cfg), bodies, headers
+}
+
+func TestGatewayProviderSendsChatCompletion(t *testing.T) {
+	gateway, bodies, headers := startGateway(t, http.StatusOK, "hello")
+
+	resp, err := gateway.Complete(context.Background(), CompletionRequest{SystemPrompt: "be brief", UserPrompt: "hi"})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Content != "hello" || resp.Provider != ProviderGateway || resp.Model != "default-model" {
+		t.Errorf("response = %+v", resp)
+	}
+	body := <-bodies
+	if body["model"] != "default-model" {
+		t.Errorf("model = %v", body["model"])
+	}
+	if _, ok := body["response_format"]; ok {
+		t.Errorf("response_format sent for a plain request: %v", body["response_format"])
+	}
+	messages, _ := body["messages"].([]any)
+	if len(messages) != 2 {
+		t.Fatalf("messages = %v", body["messages"])
+	}
+	if first, _ := messages[0].(map[string]any); first["role"] != "system" || first["content"] != "be brief" {
+		t.Errorf("first message = %v", messages[0])
+	}
+	if got := (<-headers).Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization header = %q", got)
+	}
+}
+
+func TestGatewayProviderResponseFormat(t *testing.T) {
+	gateway, bodies, _ := startGateway(t, http.StatusOK, "{}")
+
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{"summary": {Type: "string"}}, Required: []string{"summary"}}
+	if _, err := gateway.Complete(context.Background(), CompletionRequest{UserPrompt: "x", Model: "override", Schema: schema, SchemaName: "call_insights"}); err != nil {
+		t.Fatal(err)
+	}
+	body := <-bodies
+	if body["model"] != "override" {
+		t.Errorf("model = %v, want the request override", body["model"])
+	}
+	format, _ := body["response_format"].(map[string]any)
+	jsonSchema, _ := format["json_schema"].(map[string]any)
+	if format["type"] != "json_schema" || jsonSchema["name"] != "call_insights" {
+		t.Errorf("response_format = %v", body["response_format"])
+	}
+	if sent, _ := jsonSchema["schema"].(map[string]any); sent["type"] != "object" {
+		t.Errorf("schema = %v", jsonSchema["schema"])
+	}
+
+	if _, err := gateway.Complete(context.Background(), CompletionRequest{UserPrompt: "x", JSONMode: true}); err != nil {
+		t.Fatal(err)
+	}
+	format, _ = (<-bodies)["response_format"].(map[string]any)
+	if format["type"] != "json_object" {
+		t.Errorf("JSON mode response_format = %v", format)
+	}
+}
+
+func TestGatewayProviderReportsStatus(t *testing.T) {
+	gateway, _, _ := startGateway(t, http.StatusServiceUnavailable, "")
+
+	_, err := gateway.Complete(context.Background(), CompletionRequest{UserPrompt: "x"})
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("Complete error = %v, want the gateway status", err)
+	}
+}
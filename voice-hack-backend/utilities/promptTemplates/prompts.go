@@ -0,0 +1,190 @@
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"voice-hack-backend/config"
+)
+
+// Prompt names, one <name>.tmpl file per version
+const (
+	System       = "system"        // system prompt of insight generation
+	User         = "user"          // user prompt carrying the call transcripts
+	FinalSummary = "final_summary" // system prompt aggregating stored insights
+	CustomRules  = "custom_rules"  // long-form domain rules, available to the other templates
+	Compliance   = "compliance"    // system prompt checking one call against the resolution steps
+)
+
+// RequiredNames must be present in every version
+var RequiredNames = []string{System, User, FinalSummary, Compliance}
+
+//go:embed templates
+var embedded embed.FS
+
+// funcs are available to every template
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"inc":   func(i int) int { return i + 1 },
+}
+
+// Registry holds the parsed templates keyed by version and name
+type Registry struct {
+	versions map[string]*template.Template // every file of a version parsed into one set
+}
+
+// Load parses the embedded templates, then the files under dir (when set). A file under dir replaces the
+// embedded file of the same version and name; the other embedded files of that version are kept.
+func Load(dir string) (*Registry, error) {
+	files := map[string]map[string]string{} // version -> name -> source
+	if err := readTemplates(embedded, "templates", files); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := readTemplates(os.DirFS(dir), ".", files); err != nil {
+			return nil, fmt.Errorf("failed to read prompts dir %s: %w", dir, err)
+		}
+	}
+
+	registry := &Registry{versions: map[string]*template.Template{}}
+	var errs []error
+	for version, sources := range files {
+		set := template.New(version).Funcs(funcs).Option("missingkey=error")
+		for name, source := range sources {
+			if _, err := set.New(name).Parse(source); err != nil {
+				errs = append(errs, fmt.Errorf("prompt %s/%s: %w", version, name, err))
+			}
+		}
+		for _, name := range RequiredNames {
+			if _, ok := sources[name]; !ok {
+				errs = append(errs, fmt.Errorf("prompt version %s: missing %s.tmpl", version, name))
+			}
+		}
+		registry.versions[version] = set
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return registry, nil
+}
+
+// readTemplates adds every <version>/<name>.tmpl file under root to files
+func readTemplates(fsys fs.FS, root string, files map[string]map[string]string) error {
+	matches, err := fs.Glob(fsys, path.Join(root, "*", "*.tmpl"))
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		source, err := fs.ReadFile(fsys, match)
+		if err != nil {
+			return err
+		}
+		version := path.Base(path.Dir(match))
+		if files[version] == nil {
+			files[version] = map[string]string{}
+		}
+		files[version][strings.TrimSuffix(path.Base(match), ".tmpl")] = string(source)
+	}
+	return nil
+}
+
+// Has reports whether version was loaded
+func (r *Registry) Has(version string) bool {
+	_, ok := r.versions[version]
+	return ok
+}
+
+// Versions lists the loaded versions in order
+func (r *Registry) Versions() []string {
+	versions := make([]string, 0, len(r.versions))
+	for version := range r.versions {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Render executes the template name of version with data
+func (r *Registry) Render(name string, version string, data any) (string, error) {
+	set, ok := r.versions[version]
+	if !ok {
+		return "", fmt.Errorf("unknown prompt version %q", version)
+	}
+	if set.Lookup(name) == nil {
+		return "", fmt.Errorf("prompt version %s has no %s template", version, name)
+	}
+	var b bytes.Buffer
+	if err := set.ExecuteTemplate(&b, name, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s/%s: %w", version, name, err)
+	}
+	return b.String(), nil
+}
+
+var (
+	registryMu    sync.RWMutex
+	registry      *Registry
+	activeVersion = config.Defaults().Prompts.Version
+)
+
+// Configure loads the templates from cfg.Dir and makes cfg.Version the default version
+func Configure(cfg config.PromptsConfig) error {
+	loaded, err := Load(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	if !loaded.Has(cfg.Version) {
+		return fmt.Errorf("prompts.version: %q is not one of %s", cfg.Version, strings.Join(loaded.Versions(), ", "))
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = loaded
+	activeVersion = cfg.Version
+	return nil
+}
+
+// Default returns the configured registry, loading the embedded templates when Configure was not called
+func Default() (*Registry, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if registry == nil {
+		loaded, err := Load("")
+		if err != nil {
+			return nil, err
+		}
+		registry = loaded
+	}
+	return registry, nil
+}
+
+// ActiveVersion is the version used when a request sets none
+func ActiveVersion() string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return activeVersion
+}
+
+// Resolve returns version, or the active version when version is empty
+func Resolve(version string) string {
+	if version == "" {
+		return ActiveVersion()
+	}
+	return version
+}
+
+// Render executes the template name of version (the active version when empty) from the default registry
+func Render(name string, version string, data any) (string, error) {
+	loaded, err := Default()
+	if err != nil {
+		return "", err
+	}
+	return loaded.Render(name, Resolve(version), data)
+}
@@ -0,0 +1,119 @@
+package redaction
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kinds of personal data, used in placeholders such as [PHONE_1] and in redaction.detectors
+const (
+	KindEmail   = "EMAIL"
+	KindUPI     = "UPI"
+	KindGSTIN   = "GSTIN"
+	KindPAN     = "PAN"
+	KindIFSC    = "IFSC"
+	KindCard    = "CARD"
+	KindAadhaar = "AADHAAR"
+	KindPhone   = "PHONE"
+	KindPincode = "PINCODE"
+	KindAddress = "ADDRESS"
+)
+
+// Detector finds one kind of value; Valid, when set, rejects matches that fail a checksum
+type Detector struct {
+	Kind    string
+	Pattern *regexp.Regexp
+	Group   int // submatch holding the value, 0 for the whole match
+	Valid   func(value string) bool
+}
+
+// Detectors run in this order: GSTIN before PAN (a GSTIN contains a PAN), email before UPI,
+// card before Aadhaar and phone (longer digit runs first). The address detector only catches the street
+// part (house number, capitalised names, then a street word); the city is kept for the insights.
+var Detectors = []Detector{
+	{Kind: KindEmail, Pattern: regexp.MustCompile(`(?i)\b[a-z0-9._%+\-]+@[a-z0-9\-]+(?:\.[a-z0-9\-]+)*\.[a-z]{2,}\b`)},
+	{Kind: KindUPI, Pattern: regexp.MustCompile(`(?i)\b[a-z0-9.\-_]{2,256}@[a-z]{2,64}\b`)},
+	{Kind: KindGSTIN, Pattern: regexp.MustCompile(`(?i)\b\d{2}[a-z]{5}\d{4}[a-z][1-9a-z]z[0-9a-z]\b`)},
+	{Kind: KindPAN, Pattern: regexp.MustCompile(`(?i)\b[a-z]{3}[abcfghljpt][a-z]\d{4}[a-z]\b`)},
+	{Kind: KindIFSC, Pattern: regexp.MustCompile(`(?i)\b[a-z]{4}0[a-z0-9]{6}\b`), Valid: branchHasDigit},
+	{Kind: KindCard, Pattern: regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`), Valid: luhn},
+	{Kind: KindAadhaar, Pattern: regexp.MustCompile(`\b[2-9]\d{3}[ \-]?\d{4}[ \-]?\d{4}\b`), Valid: verhoeff},
+	{Kind: KindPhone, Pattern: regexp.MustCompile(`(?:\+91[ \-]?|\b(?:91[ \-]?|0)?)[6-9]\d{4}[ \-]?\d{5}\b`)},
+	{Kind: KindPincode, Pattern: regexp.MustCompile(`(?i)\bpin(?:[ \-]?code)?\s*(?:no\.?|number|is|:|-)?\s*([1-9]\d{2}[ ]?\d{3})\b`), Group: 1},
+	{Kind: KindAddress, Pattern: regexp.MustCompile(`\b(?:(?i:flat|house|plot|shop|office|h)\.?\s*(?i:no\.?|number)?\s*[#:\-]?\s*)?[A-Za-z]?[\-/]?\d{1,5}[A-Za-z]?(?:[/\-]\d{1,5}[A-Za-z]?)?,?\s+(?:[A-Z][A-Za-z.]*,?\s+){0,3}(?i:road|street|marg|lane|gali|nagar|colony|sector|layout|vihar|enclave|apartments?|society|complex|chowk|bazaar|market)\b(?:\s+\d{1,4}[A-Za-z]?\b)?`)},
+}
+
+// Kinds lists every detector kind in run order
+func Kinds() []string {
+	kinds := make([]string, 0, len(Detectors))
+	for _, detector := range Detectors {
+		kinds = append(kinds, detector.Kind)
+	}
+	return kinds
+}
+
+// IsKind reports whether kind names a detector, case-insensitively
+func IsKind(kind string) bool {
+	for _, detector := range Detectors {
+		if strings.EqualFold(detector.Kind, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+func digitsOf(value string) []int {
+	digits := make([]int, 0, len(value))
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	return digits
+}
+
+// luhn validates card numbers
+func luhn(value string) bool {
+	digits := digitsOf(value)
+	sum := 0
+	for i := range digits {
+		digit := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return len(digits) >= 13 && sum%10 == 0
+}
+
+var (
+	verhoeffMultiply = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {1, 2, 3, 4, 0, 6, 7, 8, 9, 5}, {2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7}, {4, 0, 1, 2, 3, 9, 5, 6, 7, 8}, {5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2}, {7, 6, 5, 9, 8, 2, 1, 0, 4, 3}, {8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffPermute = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {1, 5, 7, 6, 2, 8, 3, 0, 9, 4}, {5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7}, {9, 4, 5, 3, 1, 2, 6, 8, 7, 0}, {4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5}, {7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+// verhoeff validates the Aadhaar check digit, which keeps other 12-digit numbers out
+func verhoeff(value string) bool {
+	digits := digitsOf(value)
+	check := 0
+	for i := range digits {
+		check = verhoeffMultiply[check][verhoeffPermute[i%8][digits[len(digits)-1-i]]]
+	}
+	return len(digits) == 12 && check == 0
+}
+
+// branchHasDigit keeps 11-letter words with a 0 in fifth place out of the IFSC matches, branch codes contain a digit
+func branchHasDigit(value string) bool {
+	return strings.ContainsAny(value[5:], "0123456789")
+}
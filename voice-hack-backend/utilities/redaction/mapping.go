@@ -0,0 +1,66 @@
+package redaction
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// storedMapping is the file kept per redaction ID under redaction.mapping_dir
+type storedMapping struct {
+	ID        string            `json:"id"`
+	Mapping   map[string]string `json:"mapping"` // placeholder -> original value
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+var mappingID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// KeepMapping reports whether SaveMapping should be called for redacted requests
+func KeepMapping() bool {
+	return currentConfig().KeepMapping
+}
+
+// SaveMapping writes mapping to the server-side mapping directory and returns its redaction ID.
+// The file is only readable by the server user; the mapping never leaves the server.
+func SaveMapping(mapping map[string]string) (string, error) {
+	dir := currentConfig().MappingDir
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create redaction mapping dir: %w", err)
+	}
+
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", err
+	}
+	stored := storedMapping{ID: hex.EncodeToString(idBytes), Mapping: mapping, CreatedAt: time.Now().UTC()}
+	mappingBytes, err := json.Marshal(stored)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, stored.ID+".json"), mappingBytes, 0600); err != nil {
+		return "", fmt.Errorf("failed to write redaction mapping: %w", err)
+	}
+	return stored.ID, nil
+}
+
+// LoadMapping reads the mapping saved under id, for use with Restore
+func LoadMapping(id string) (map[string]string, error) {
+	if !mappingID.MatchString(id) {
+		return nil, errors.New("invalid redaction id")
+	}
+	mappingBytes, err := os.ReadFile(filepath.Join(currentConfig().MappingDir, id+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redaction mapping %s: %w", id, err)
+	}
+	var stored storedMapping
+	if err := json.Unmarshal(mappingBytes, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse redaction mapping %s: %w", id, err)
+	}
+	return stored.Mapping, nil
+}
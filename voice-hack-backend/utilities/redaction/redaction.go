@@ -0,0 +1,185 @@
+package redaction
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"voice-hack-backend/config"
+)
+
+// Destinations that can each be configured to receive redacted text
+const (
+	DestinationLLM      = "llm"       // prompts sent to the LLM provider
+	DestinationVectorDB = "vector_db" // text embedded for the sample call search
+	DestinationLogs     = "logs"      // transcripts written to the application logs
+)
+
+var (
+	redactionMu     sync.RWMutex
+	redactionConfig = config.Defaults().Redaction
+)
+
+// Configure sets the destinations, detectors and mapping storage used by this package
+func Configure(cfg config.RedactionConfig) {
+	redactionMu.Lock()
+	defer redactionMu.Unlock()
+	redactionConfig = cfg
+}
+
+func currentConfig() config.RedactionConfig {
+	redactionMu.RLock()
+	defer redactionMu.RUnlock()
+	return redactionConfig
+}
+
+// Enabled reports whether text sent to destination must be redacted
+func Enabled(destination string) bool {
+	cfg := currentConfig()
+	switch destination {
+	case DestinationLLM:
+		return cfg.LLM
+	case DestinationVectorDB:
+		return cfg.VectorDB
+	case DestinationLogs:
+		return cfg.Logs
+	}
+	return true
+}
+
+// Redact redacts text for destination when that destination is enabled, keeping no mapping
+func Redact(destination string, text string) string {
+	if !Enabled(destination) {
+		return text
+	}
+	return NewRedactor().Redact(text)
+}
+
+// Redactor replaces personal data with typed placeholders such as [PHONE_1]. The same value gets
+// the same placeholder across every text of one Redactor, so the LLM can still tell values apart.
+type Redactor struct {
+	detectors []Detector
+
+	mu           sync.Mutex
+	placeholders map[string]string // normalized kind:value -> placeholder
+	mapping      map[string]string // placeholder -> original value
+	counts       map[string]int
+}
+
+// NewRedactor returns a Redactor using the configured detectors, or all of them when none are configured
+func NewRedactor() *Redactor {
+	enabled := currentConfig().Detectors
+	detectors := make([]Detector, 0, len(Detectors))
+	for _, detector := range Detectors {
+		if len(enabled) == 0 || containsFold(enabled, detector.Kind) {
+			detectors = append(detectors, detector)
+		}
+	}
+	return &Redactor{
+		detectors:    detectors,
+		placeholders: map[string]string{},
+		mapping:      map[string]string{},
+		counts:       map[string]int{},
+	}
+}
+
+// Redact returns text with every detected value replaced by its placeholder
+func (r *Redactor) Redact(text string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, detector := range r.detectors {
+		matches := detector.Pattern.FindAllStringSubmatchIndex(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, match := range matches {
+			start, end := match[2*detector.Group], match[2*detector.Group+1]
+			if start < 0 {
+				continue
+			}
+			value := text[start:end]
+			if detector.Valid != nil && !detector.Valid(value) {
+				continue
+			}
+			b.WriteString(text[last:start])
+			b.WriteString(r.placeholder(detector.Kind, value))
+			last = end
+		}
+		b.WriteString(text[last:])
+		text = b.String()
+	}
+	return text
+}
+
+func (r *Redactor) placeholder(kind string, value string) string {
+	key := kind + ":" + normalize(kind, value)
+	if placeholder, ok := r.placeholders[key]; ok {
+		return placeholder
+	}
+	r.counts[kind]++
+	placeholder := fmt.Sprintf("[%s_%d]", kind, r.counts[kind])
+	r.placeholders[key] = placeholder
+	r.mapping[placeholder] = value
+	return placeholder
+}
+
+// Count returns the number of distinct values redacted so far
+func (r *Redactor) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.mapping)
+}
+
+// Mapping returns a copy of placeholder -> original value
+func (r *Redactor) Mapping() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mapping := make(map[string]string, len(r.mapping))
+	for placeholder, value := range r.mapping {
+		mapping[placeholder] = value
+	}
+	return mapping
+}
+
+// Restore puts the original values back into redacted text. A value written in several forms
+// (e.g. with and without +91) comes back in the first form that was seen.
+func Restore(text string, mapping map[string]string) string {
+	pairs := make([]string, 0, 2*len(mapping))
+	for placeholder, value := range mapping {
+		pairs = append(pairs, placeholder, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// normalize makes differently written forms of one value share a placeholder,
+// e.g. "+91 98765 43210" and "9876543210"
+func normalize(kind string, value string) string {
+	switch kind {
+	case KindPhone:
+		digits := strings.Map(keepDigit, value)
+		if len(digits) > 10 {
+			digits = digits[len(digits)-10:]
+		}
+		return digits
+	case KindCard, KindAadhaar, KindPincode:
+		return strings.Map(keepDigit, value)
+	}
+	return strings.ToUpper(value)
+}
+
+func keepDigit(r rune) rune {
+	if r >= '0' && r <= '9' {
+		return r
+	}
+	return -1
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
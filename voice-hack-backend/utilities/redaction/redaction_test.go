@@ -0,0 +1,180 @@
+package redaction
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"voice-hack-backend/config"
+)
+
+func useRedactionConfig(t *testing.T, cfg config.RedactionConfig) {
+	t.Helper()
+	Configure(cfg)
+	t.Cleanup(func() { Configure(config.Defaults().Redaction) })
+}
+
+func TestLuhn(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"4111 1111 1111 1111", true},
+		{"4111-1111-1111-1111", true},
+		{"5500005555555559", true},
+		{"4111 1111 1111 1112", false},
+		{"79927398713", false}, // valid checksum, too short for a card
+	}
+	for _, tt := range tests {
+		if got := luhn(tt.value); got != tt.want {
+			t.Errorf("luhn(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestVerhoeff(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"2345 6789 0124", true},
+		{"4987-1234-5679", true},
+		{"2345 6789 0125", false},
+		{"4987 1234 5678", false},
+		{"23456789012", false}, // 11 digits
+	}
+	for _, tt := range tests {
+		if got := verhoeff(tt.value); got != tt.want {
+			t.Errorf("verhoeff(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDetectors(t *testing.T) {
+	useRedactionConfig(t, config.Defaults().Redaction)
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"email", "mail ravi.kumar@example.co.in today", "mail [EMAIL_1] today"},
+		{"email spelled out", "ravi at example dot com", "ravi at example dot com"},
+		{"upi", "pay to ravi.kumar@okhdfc please", "pay to [UPI_1] please"},
+		{"upi needs a handle", "ask @ravi on chat", "ask @ravi on chat"},
+		{"gstin", "GSTIN 27AAPFU0939F1ZV", "GSTIN [GSTIN_1]"},
+		{"gstin without z", "GSTIN 27AAPFU0939F1XV", "GSTIN 27AAPFU0939F1XV"},
+		{"pan", "PAN is abcpe1234f", "PAN is [PAN_1]"},
+		{"pan holder type", "code ABCDE1234F", "code ABCDE1234F"},
+		{"ifsc", "IFSC HDFC0001234", "IFSC [IFSC_1]"},
+		{"ifsc without branch digit", "ABCD0EFGHIJ", "ABCD0EFGHIJ"},
+		{"card", "card 4111 1111 1111 1111 expires", "card [CARD_1] expires"},
+		{"card checksum", "card 5500 0055 5555 5558", "card 5500 0055 5555 5558"},
+		{"aadhaar", "aadhaar 2345 6789 0124", "aadhaar [AADHAAR_1]"},
+		{"aadhaar checksum", "aadhaar 2345 6789 0125", "aadhaar 2345 6789 0125"},
+		{"phone", "call +91 98765 43210 now", "call [PHONE_1] now"},
+		{"phone with trunk prefix", "call 09876543210", "call [PHONE_1]"},
+		{"phone starting low", "ref 12345 67890", "ref 12345 67890"},
+		{"pincode", "pin code 560 001", "pin code [PINCODE_1]"},
+		{"pincode after colon", "PIN: 110001", "PIN: [PINCODE_1]"},
+		{"pincode without keyword", "order 560001", "order 560001"},
+		{"pincode leading zero", "pin 056001", "pin 056001"},
+		{"address", "shop is at 12 MG Road, Bengaluru", "shop is at [ADDRESS_1], Bengaluru"},
+		{"address with house number", "House no 45, Sector 12, Noida", "[ADDRESS_1], Noida"},
+		{"address with block", "deliver to B-204, Shanti Apartments today", "deliver to [ADDRESS_1] today"},
+		{"address with flat", "flat 3B Lajpat Nagar", "[ADDRESS_1]"},
+		{"address needs names before the street word", "I called 3 times on the road", "I called 3 times on the road"},
+		{"address needs a street word", "we got 5 Leads from Delhi", "we got 5 Leads from Delhi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewRedactor().Redact(tt.text); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorKeepsPlaceholdersAcrossCalls(t *testing.T) {
+	useRedactionConfig(t, config.Defaults().Redaction)
+	redactor := NewRedactor()
+
+	first := redactor.Redact("call 9876543210 or mail ravi@example.com")
+	second := redactor.Redact("RAVI@EXAMPLE.COM said +91 98765 43210, the other number is 9123456789")
+	if want := "call [PHONE_1] or mail [EMAIL_1]"; first != want {
+		t.Errorf("first = %q, want %q", first, want)
+	}
+	if want := "[EMAIL_1] said [PHONE_1], the other number is [PHONE_2]"; second != want {
+		t.Errorf("second = %q, want %q", second, want)
+	}
+
+	wantMapping := map[string]string{
+		"[PHONE_1]": "9876543210",
+		"[PHONE_2]": "9123456789",
+		"[EMAIL_1]": "ravi@example.com",
+	}
+	if got := redactor.Mapping(); !reflect.DeepEqual(got, wantMapping) {
+		t.Errorf("Mapping() = %v, want %v", got, wantMapping)
+	}
+	if redactor.Count() != 3 {
+		t.Errorf("Count() = %d, want 3", redactor.Count())
+	}
+	if got, want := Restore(first, redactor.Mapping()), "call 9876543210 or mail ravi@example.com"; got != want {
+		t.Errorf("Restore() = %q, want %q", got, want)
+	}
+
+	if got := NewRedactor().Redact("call 9123456789"); got != "call [PHONE_1]" {
+		t.Errorf("a new redactor continued numbering: %q", got)
+	}
+}
+
+func TestConfiguredDetectorsAndDestinations(t *testing.T) {
+	useRedactionConfig(t, config.RedactionConfig{LLM: true, Detectors: []string{"phone"}})
+
+	text := "call 9876543210 or mail ravi@example.com"
+	if got, want := Redact(DestinationLLM, text), "call [PHONE_1] or mail ravi@example.com"; got != want {
+		t.Errorf("Redact(llm) = %q, want %q", got, want)
+	}
+	if got := Redact(DestinationVectorDB, text); got != text {
+		t.Errorf("Redact(vector_db) = %q, want it unchanged", got)
+	}
+	if got := Redact(DestinationLogs, text); got != text {
+		t.Errorf("Redact(logs) = %q, want it unchanged", got)
+	}
+}
+
+func TestSaveAndLoadMapping(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mappings")
+	useRedactionConfig(t, config.RedactionConfig{KeepMapping: true, MappingDir: dir})
+
+	mapping := map[string]string{"[PHONE_1]": "+91 98765 43210", "[PAN_1]": "ABCPE1234F"}
+	id, err := SaveMapping(mapping)
+	if err != nil {
+		t.Fatalf("SaveMapping: %v", err)
+	}
+	if !mappingID.MatchString(id) {
+		t.Errorf("SaveMapping id = %q, want 32 hex characters", id)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, id+".json"))
+	if err != nil {
+		t.Fatalf("mapping file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("mapping file mode = %v, want 0600", perm)
+	}
+
+	loaded, err := LoadMapping(id)
+	if err != nil {
+		t.Fatalf("LoadMapping: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, mapping) {
+		t.Errorf("LoadMapping() = %v, want %v", loaded, mapping)
+	}
+
+	if _, err := LoadMapping("../" + id); err == nil {
+		t.Error("LoadMapping accepted a path outside the mapping dir")
+	}
+	if _, err := LoadMapping("0123456789abcdef0123456789abcdef"); err == nil {
+		t.Error("LoadMapping found a mapping that was never saved")
+	}
+}
@@ -0,0 +1,97 @@
+package transcript
+
+import (
+	"math"
+	"strings"
+)
+
+// overlapTolerance ignores overlaps this short, diarizers often let adjacent turns touch
+const overlapTolerance = 0.3
+
+// Metrics are call-quality numbers measured from a timed, diarized transcript. Durations are seconds,
+// shares are fractions of the time either party was talking.
+type Metrics struct {
+	DurationSeconds        float64 `json:"duration_seconds"`
+	ExecutiveTalkSeconds   float64 `json:"executive_talk_seconds"`
+	SellerTalkSeconds      float64 `json:"seller_talk_seconds"`
+	ExecutiveTalkShare     float64 `json:"executive_talk_share"`
+	SellerTalkShare        float64 `json:"seller_talk_share"`
+	LongestSilenceSeconds  float64 `json:"longest_silence_seconds"`
+	HoldSeconds            float64 `json:"hold_seconds"` // total of the silences of at least the hold gap
+	HoldCount              int     `json:"hold_count"`
+	Interruptions          int     `json:"interruptions"`           // turns starting before the other party finished
+	ExecutiveInterruptions int     `json:"executive_interruptions"` // of which the executive cut the seller off
+	ExecutiveWPM           float64 `json:"executive_wpm"`           // words per minute of executive talk time
+	SellerWPM              float64 `json:"seller_wpm"`
+}
+
+// ComputeMetrics measures t, counting every silence of at least holdGap seconds as hold time.
+// ok is false when t has no timestamps or no executive/seller turns to measure.
+// Plain-text transcripts only carry start times, so their turns run until the next one and show no silence.
+func ComputeMetrics(t Transcript, holdGap float64) (metrics Metrics, ok bool) {
+	if !t.Timed || !t.Diarized() || len(t.Utterances) == 0 {
+		return metrics, false
+	}
+
+	executiveWords, sellerWords := 0, 0
+	first := t.Utterances[0].Start
+	coveredUntil := first
+	var previous *Utterance
+	for i := range t.Utterances {
+		utterance := &t.Utterances[i]
+		talk := math.Max(0, utterance.End-utterance.Start)
+		words := len(strings.Fields(utterance.Text))
+		switch utterance.Role {
+		case RoleExecutive:
+			metrics.ExecutiveTalkSeconds += talk
+			executiveWords += words
+		case RoleSeller:
+			metrics.SellerTalkSeconds += talk
+			sellerWords += words
+		}
+
+		if silence := utterance.Start - coveredUntil; silence > 0 {
+			metrics.LongestSilenceSeconds = math.Max(metrics.LongestSilenceSeconds, silence)
+			if holdGap > 0 && silence >= holdGap {
+				metrics.HoldSeconds += silence
+				metrics.HoldCount++
+			}
+		}
+		if previous != nil && previous.Role != utterance.Role && utterance.Role != RoleUnknown && previous.Role != RoleUnknown &&
+			utterance.Start < previous.End-overlapTolerance {
+			metrics.Interruptions++
+			if utterance.Role == RoleExecutive {
+				metrics.ExecutiveInterruptions++
+			}
+		}
+		coveredUntil = math.Max(coveredUntil, utterance.End)
+		previous = utterance
+	}
+
+	metrics.DurationSeconds = coveredUntil - first
+	if talk := metrics.ExecutiveTalkSeconds + metrics.SellerTalkSeconds; talk > 0 {
+		metrics.ExecutiveTalkShare = round(metrics.ExecutiveTalkSeconds/talk, 2)
+		metrics.SellerTalkShare = round(metrics.SellerTalkSeconds/talk, 2)
+	}
+	metrics.ExecutiveWPM = wordsPerMinute(executiveWords, metrics.ExecutiveTalkSeconds)
+	metrics.SellerWPM = wordsPerMinute(sellerWords, metrics.SellerTalkSeconds)
+
+	metrics.DurationSeconds = round(metrics.DurationSeconds, 1)
+	metrics.ExecutiveTalkSeconds = round(metrics.ExecutiveTalkSeconds, 1)
+	metrics.SellerTalkSeconds = round(metrics.SellerTalkSeconds, 1)
+	metrics.LongestSilenceSeconds = round(metrics.LongestSilenceSeconds, 1)
+	metrics.HoldSeconds = round(metrics.HoldSeconds, 1)
+	return metrics, true
+}
+
+func wordsPerMinute(words int, seconds float64) float64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return round(float64(words)/(seconds/60), 0)
+}
+
+func round(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,81 @@
+package transcript
+
+import (
+	"strings"
+	"testing"
+)
+
+// turn is an utterance of the given number of words
+func turn(role string, start, end float64, words int) Utterance {
+	return Utterance{Role: role, Start: start, End: end, Text: strings.TrimSpace(strings.Repeat("word ", words))}
+}
+
+func TestComputeMetrics(t *testing.T) {
+	call := Transcript{Timed: true, Utterances: []Utterance{
+		turn(RoleExecutive, 0, 10, 10),
+		turn(RoleSeller, 9.8, 20, 13),  // overlaps by 0.2s, within the tolerance
+		turn(RoleExecutive, 25, 30, 5), // after a 5s silence, exactly the hold gap
+		turn(RoleSeller, 29.5, 35, 7),  // the seller cuts in 0.5s early
+		turn(RoleExecutive, 34, 40, 6), // the executive cuts in 1s early
+		turn(RoleUnknown, 39, 41, 3),   // unknown speakers never interrupt
+		turn(RoleSeller, 45.5, 51, 6),  // after a 4.5s silence, below the hold gap
+	}}
+
+	got, ok := ComputeMetrics(call, 5)
+	if !ok {
+		t.Fatal("ComputeMetrics did not measure a timed, diarized call")
+	}
+	want := Metrics{
+		DurationSeconds:        51,
+		ExecutiveTalkSeconds:   21,
+		SellerTalkSeconds:      21.2,
+		ExecutiveTalkShare:     0.5,
+		SellerTalkShare:        0.5,
+		LongestSilenceSeconds:  5,
+		HoldSeconds:            5,
+		HoldCount:              1,
+		Interruptions:          2,
+		ExecutiveInterruptions: 1,
+		ExecutiveWPM:           60,
+		SellerWPM:              74,
+	}
+	if got != want {
+		t.Errorf("ComputeMetrics\n got %+v\nwant %+v", got, want)
+	}
+
+	if got, _ := ComputeMetrics(call, 0); got.HoldCount != 0 || got.HoldSeconds != 0 || got.LongestSilenceSeconds != 5 {
+		t.Errorf("without a hold gap got %d holds of %vs, longest silence %vs", got.HoldCount, got.HoldSeconds, got.LongestSilenceSeconds)
+	}
+	if got, _ := ComputeMetrics(call, 4.5); got.HoldCount != 2 || got.HoldSeconds != 9.5 {
+		t.Errorf("with a 4.5s hold gap got %d holds of %vs, want 2 of 9.5s", got.HoldCount, got.HoldSeconds)
+	}
+}
+
+func TestComputeMetricsTalkShare(t *testing.T) {
+	got, ok := ComputeMetrics(Transcript{Timed: true, Utterances: []Utterance{
+		turn(RoleExecutive, 0, 30, 45),
+		turn(RoleSeller, 30, 40, 5),
+		turn(RoleExecutive, 40, 40, 0), // zero-length turns add no talk time
+	}}, 5)
+	if !ok {
+		t.Fatal("ComputeMetrics did not measure the call")
+	}
+	if got.ExecutiveTalkShare != 0.75 || got.SellerTalkShare != 0.25 {
+		t.Errorf("talk share = %v/%v, want 0.75/0.25", got.ExecutiveTalkShare, got.SellerTalkShare)
+	}
+	if got.ExecutiveWPM != 90 || got.SellerWPM != 30 {
+		t.Errorf("wpm = %v/%v, want 90/30", got.ExecutiveWPM, got.SellerWPM)
+	}
+}
+
+func TestComputeMetricsNeedsTimesAndSpeakers(t *testing.T) {
+	for name, call := range map[string]Transcript{
+		"untimed":       {Utterances: []Utterance{turn(RoleExecutive, 0, 0, 3)}},
+		"not diarized":  {Timed: true, Utterances: []Utterance{turn(RoleUnknown, 0, 5, 3)}},
+		"no utterances": {Timed: true},
+	} {
+		if _, ok := ComputeMetrics(call, 5); ok {
+			t.Errorf("%s: ComputeMetrics measured the call", name)
+		}
+	}
+}
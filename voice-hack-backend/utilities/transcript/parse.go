@@ -0,0 +1,261 @@
+package transcript
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parse detects the format of raw (WebVTT, SRT, JSON or plain text) and returns the
+// utterances with executive/seller roles assigned
+func Parse(raw string) (t Transcript, err error) {
+	raw = strings.TrimPrefix(raw, "\uFEFF")
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return t, errors.New("transcript is empty")
+	}
+
+	switch {
+	case strings.HasPrefix(trimmed, "WEBVTT"):
+		t, err = parseCues(trimmed, FormatVTT)
+	case srtStart.MatchString(trimmed):
+		t, err = parseCues(trimmed, FormatSRT)
+	case (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)):
+		t, err = parseJSON(trimmed)
+	default:
+		t = parsePlain(trimmed)
+	}
+	if err != nil {
+		return t, err
+	}
+	if len(t.Utterances) == 0 {
+		return t, fmt.Errorf("%s transcript has no utterances", t.Format)
+	}
+	assignRoles(&t)
+	return t, nil
+}
+
+// ParseOrPlain is Parse that never fails: unparseable text becomes a single utterance of unknown speaker
+func ParseOrPlain(raw string) Transcript {
+	t, err := Parse(raw)
+	if err != nil {
+		return Transcript{Format: FormatPlain, Utterances: []Utterance{{Role: RoleUnknown, Text: strings.TrimSpace(raw)}}}
+	}
+	return t
+}
+
+var (
+	srtStart   = regexp.MustCompile(`^\d+\n[\d:,.]+\s*-->`)
+	cueTiming  = regexp.MustCompile(`^([\d:.,]+)\s*-->\s*([\d:.,]+)`)
+	vttVoice   = regexp.MustCompile(`^<v(?:\.[^ >]*)?\s+([^>]+)>(.*?)(?:</v>)?$`)
+	htmlTag    = regexp.MustCompile(`</?[^>]+>`)
+	speakerPfx = regexp.MustCompile(`^([\p{L}][\p{L}\d _.\-]{0,30}?)\s*:\s+(.+)$`)
+	plainClock = regexp.MustCompile(`^\[?(\d{1,2}:\d{2}(?::\d{2})?(?:[.,]\d+)?)\]?\s+(.*)$`)
+)
+
+// parseCues reads WebVTT and SRT: blocks separated by blank lines with a "start --> end" timing
+// line. The speaker comes from a <v Name> voice tag or a "Name:" prefix on the cue text.
+func parseCues(raw, format string) (t Transcript, err error) {
+	t = Transcript{Format: format, Timed: true}
+	for _, block := range strings.Split(raw, "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		timingAt := -1
+		for i, line := range lines {
+			if strings.Contains(line, "-->") {
+				timingAt = i
+				break
+			}
+		}
+		if timingAt < 0 {
+			// WEBVTT header, NOTE and STYLE blocks
+			continue
+		}
+		match := cueTiming.FindStringSubmatch(strings.TrimSpace(lines[timingAt]))
+		if match == nil {
+			return t, fmt.Errorf("%s cue has an invalid timing line %q", format, lines[timingAt])
+		}
+		start, startErr := parseClock(match[1])
+		end, endErr := parseClock(match[2])
+		if startErr != nil || endErr != nil {
+			return t, fmt.Errorf("%s cue has an invalid timing line %q", format, lines[timingAt])
+		}
+
+		speaker := ""
+		texts := []string{}
+		for _, line := range lines[timingAt+1:] {
+			line = strings.TrimSpace(line)
+			if voice := vttVoice.FindStringSubmatch(line); voice != nil {
+				speaker, line = strings.TrimSpace(voice[1]), voice[2]
+			}
+			if line = strings.TrimSpace(htmlTag.ReplaceAllString(line, "")); line != "" {
+				texts = append(texts, line)
+			}
+		}
+		text := strings.Join(texts, " ")
+		if speaker == "" {
+			speaker, text = splitSpeaker(text)
+		}
+		if text == "" {
+			continue
+		}
+		t.Utterances = appendTurn(t.Utterances, Utterance{Speaker: speaker, Start: start, End: end, Text: text})
+	}
+	return t, nil
+}
+
+// jsonUtterance accepts the field names used by the media service, Whisper/pyannote
+// segment output and our own Utterance
+type jsonUtterance struct {
+	Speaker     string   `json:"speaker"`
+	SpeakerName string   `json:"speaker_name"`
+	Channel     *int     `json:"channel"`
+	Role        string   `json:"role"`
+	Start       *float64 `json:"start"`
+	End         *float64 `json:"end"`
+	StartTime   *float64 `json:"start_time"`
+	EndTime     *float64 `json:"end_time"`
+	Text        string   `json:"text"`
+	Transcript  string   `json:"transcript"`
+}
+
+// parseJSON reads an array of utterances or an object holding one under utterances, segments or results
+func parseJSON(raw string) (t Transcript, err error) {
+	t = Transcript{Format: FormatJSON}
+	var items []jsonUtterance
+	if strings.HasPrefix(raw, "[") {
+		err = json.Unmarshal([]byte(raw), &items)
+	} else {
+		var wrapper struct {
+			Utterances []jsonUtterance `json:"utterances"`
+			Segments   []jsonUtterance `json:"segments"`
+			Results    []jsonUtterance `json:"results"`
+			Text       string          `json:"text"`
+		}
+		err = json.Unmarshal([]byte(raw), &wrapper)
+		switch {
+		case len(wrapper.Utterances) > 0:
+			items = wrapper.Utterances
+		case len(wrapper.Segments) > 0:
+			items = wrapper.Segments
+		case len(wrapper.Results) > 0:
+			items = wrapper.Results
+		case wrapper.Text != "":
+			items = []jsonUtterance{{Text: wrapper.Text}}
+		}
+	}
+	if err != nil {
+		return t, fmt.Errorf("invalid JSON transcript: %w", err)
+	}
+
+	timed := len(items) > 0
+	for _, item := range items {
+		utterance := Utterance{Speaker: item.Speaker, Text: strings.TrimSpace(item.Text)}
+		if utterance.Speaker == "" {
+			utterance.Speaker = item.SpeakerName
+		}
+		if utterance.Speaker == "" && item.Channel != nil {
+			utterance.Speaker = "channel_" + strconv.Itoa(*item.Channel)
+		}
+		if utterance.Text == "" {
+			utterance.Text = strings.TrimSpace(item.Transcript)
+		}
+		if role := strings.ToLower(item.Role); role == RoleExecutive || role == RoleSeller {
+			utterance.Role = role
+		}
+		start, end := item.Start, item.End
+		if start == nil {
+			start, end = item.StartTime, item.EndTime
+		}
+		if start == nil || end == nil {
+			timed = false
+		} else {
+			utterance.Start, utterance.End = *start, *end
+		}
+		if utterance.Text == "" {
+			continue
+		}
+		t.Utterances = append(t.Utterances, utterance)
+	}
+	t.Timed = timed
+	return t, nil
+}
+
+// parsePlain reads "Speaker: text" lines with an optional leading [hh:mm:ss] timestamp. Lines
+// without a speaker continue the previous turn; text without any speaker is one utterance.
+func parsePlain(raw string) Transcript {
+	t := Transcript{Format: FormatPlain, Timed: true}
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		start, stamped := 0.0, false
+		if match := plainClock.FindStringSubmatch(line); match != nil {
+			if seconds, err := parseClock(match[1]); err == nil {
+				start, stamped, line = seconds, true, match[2]
+			}
+		}
+		speaker, text := splitSpeaker(line)
+		if speaker == "" && !stamped && len(t.Utterances) > 0 {
+			last := &t.Utterances[len(t.Utterances)-1]
+			last.Text += " " + text
+			continue
+		}
+		if !stamped {
+			t.Timed = false
+		}
+		t.Utterances = append(t.Utterances, Utterance{Speaker: speaker, Start: start, Text: text})
+	}
+	// Plain text only carries start times, a turn ends where the next one starts
+	if t.Timed {
+		for i := range t.Utterances {
+			if i+1 < len(t.Utterances) {
+				t.Utterances[i].End = t.Utterances[i+1].Start
+			} else {
+				t.Utterances[i].End = t.Utterances[i].Start
+			}
+		}
+	}
+	return t
+}
+
+// splitSpeaker separates a "Name: text" prefix, leaving text untouched when there is none
+func splitSpeaker(line string) (speaker, text string) {
+	if match := speakerPfx.FindStringSubmatch(line); match != nil {
+		return strings.TrimSpace(match[1]), strings.TrimSpace(match[2])
+	}
+	return "", strings.TrimSpace(line)
+}
+
+// appendTurn merges back-to-back cues of the same labelled speaker into one utterance, keeping
+// pauses of a second or more (hold, silence) as separate turns
+func appendTurn(utterances []Utterance, utterance Utterance) []Utterance {
+	if n := len(utterances); n > 0 && utterance.Speaker != "" && utterances[n-1].Speaker == utterance.Speaker &&
+		utterance.Start-utterances[n-1].End < 1 {
+		utterances[n-1].Text += " " + utterance.Text
+		utterances[n-1].End = utterance.End
+		return utterances
+	}
+	return append(utterances, utterance)
+}
+
+// parseClock reads hh:mm:ss.mmm, mm:ss and the SRT comma form into seconds
+func parseClock(clock string) (float64, error) {
+	parts := strings.Split(strings.Replace(clock, ",", ".", 1), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", clock)
+	}
+	seconds := 0.0
+	for _, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid timestamp %q", clock)
+		}
+		seconds = seconds*60 + value
+	}
+	return seconds, nil
+}
@@ -0,0 +1,155 @@
+package transcript
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		raw    string
+		format string
+		timed  bool
+		want   []Utterance
+	}{
+		{
+			name: "webvtt with voice tags",
+			raw: "WEBVTT\n\nNOTE recorded by the dialer\n\n" +
+				"00:00:01.000 --> 00:00:04.500\n<v Agent>Namaste, IndiaMART se bol raha hoon</v>\n\n" +
+				"00:00:05.000 --> 00:00:07.000\n<v Customer>Haan, boliye\n\n" +
+				"00:00:07.500 --> 00:00:09.000\n<v Customer><b>leads</b> nahi aa rahe</v>",
+			format: FormatVTT, timed: true,
+			want: []Utterance{
+				{Speaker: "Agent", Role: RoleExecutive, Start: 1, End: 4.5, Text: "Namaste, IndiaMART se bol raha hoon"},
+				{Speaker: "Customer", Role: RoleSeller, Start: 5, End: 9, Text: "Haan, boliye leads nahi aa rahe"},
+			},
+		},
+		{
+			name: "srt with speaker prefixes and CRLF",
+			raw: "\uFEFF1\r\n00:00:01,000 --> 00:00:03,000\r\nSPEAKER_00: Hello, this is IndiaMART\r\n\r\n" +
+				"2\r\n00:01:02,500 --> 00:01:04,000\r\nSPEAKER_01: I want more buyers\r\n",
+			format: FormatSRT, timed: true,
+			want: []Utterance{
+				{Speaker: "SPEAKER_00", Role: RoleExecutive, Start: 1, End: 3, Text: "Hello, this is IndiaMART"},
+				{Speaker: "SPEAKER_01", Role: RoleSeller, Start: 62.5, End: 64, Text: "I want more buyers"},
+			},
+		},
+		{
+			name:   "json array with start and end",
+			raw:    `[{"speaker":"A","role":"Executive","start":0,"end":2.5,"text":"Hello"},{"speaker":"B","start":3,"end":4,"text":" Hi "}]`,
+			format: FormatJSON, timed: true,
+			want: []Utterance{
+				{Speaker: "A", Role: RoleExecutive, Start: 0, End: 2.5, Text: "Hello"},
+				{Speaker: "B", Role: RoleSeller, Start: 3, End: 4, Text: "Hi"},
+			},
+		},
+		{
+			name:   "json segments with channels and start_time",
+			raw:    `{"segments":[{"channel":0,"start_time":1,"end_time":2,"transcript":"Good morning"},{"channel":1,"start_time":2,"end_time":3,"transcript":"Morning"}]}`,
+			format: FormatJSON, timed: true,
+			want: []Utterance{
+				{Speaker: "channel_0", Role: RoleExecutive, Start: 1, End: 2, Text: "Good morning"},
+				{Speaker: "channel_1", Role: RoleSeller, Start: 2, End: 3, Text: "Morning"},
+			},
+		},
+		{
+			name:   "json text only is untimed",
+			raw:    `{"text":"just the words"}`,
+			format: FormatJSON, timed: false,
+			want: []Utterance{{Role: RoleUnknown, Text: "just the words"}},
+		},
+		{
+			name:   "plain with timestamps",
+			raw:    "[00:05] Seller: my catalogue is not visible\n[00:12] Executive: let me check\nit takes a day",
+			format: FormatPlain, timed: true,
+			want: []Utterance{
+				{Speaker: "Seller", Role: RoleSeller, Start: 5, End: 12, Text: "my catalogue is not visible"},
+				{Speaker: "Executive", Role: RoleExecutive, Start: 12, End: 12, Text: "let me check it takes a day"},
+			},
+		},
+		{
+			name:   "plain without timestamps",
+			raw:    "Rahul: Hello ji, IndiaMART se\nSeller: haan",
+			format: FormatPlain, timed: false,
+			want: []Utterance{
+				{Speaker: "Rahul", Role: RoleExecutive, Text: "Hello ji, IndiaMART se"},
+				{Speaker: "Seller", Role: RoleSeller, Text: "haan"},
+			},
+		},
+		{
+			name:   "plain without speakers",
+			raw:    "the whole call as one block\nof text",
+			format: FormatPlain, timed: false,
+			want: []Utterance{{Role: RoleUnknown, Text: "the whole call as one block of text"}},
+		},
+	} {
+		got, err := Parse(tc.raw)
+		if err != nil {
+			t.Errorf("%s: Parse: %v", tc.name, err)
+			continue
+		}
+		if got.Format != tc.format || got.Timed != tc.timed {
+			t.Errorf("%s: format %q timed %v, want %q timed %v", tc.name, got.Format, got.Timed, tc.format, tc.timed)
+		}
+		if !reflect.DeepEqual(got.Utterances, tc.want) {
+			t.Errorf("%s: utterances\n got %+v\nwant %+v", tc.name, got.Utterances, tc.want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"empty", "  \n ", "empty"},
+		{"vtt without cues", "WEBVTT\n\nNOTE nothing here", "no utterances"},
+		{"bad cue timing", "WEBVTT\n\nxx:01 --> 00:02\nhello", "invalid timing"},
+		{"bad json", `{"utterances": [{"text": 5}]}`, "invalid JSON"},
+	} {
+		_, err := Parse(tc.raw)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: Parse error = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+	if got := ParseOrPlain("WEBVTT\n\nNOTE nothing here"); got.Format != FormatPlain || len(got.Utterances) != 1 || got.Utterances[0].Role != RoleUnknown {
+		t.Errorf("ParseOrPlain = %+v, want the raw text as one unknown utterance", got)
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	for _, tc := range []struct {
+		clock string
+		want  float64
+		ok    bool
+	}{
+		{"00:01.500", 1.5, true},
+		{"01:02:03,250", 3723.25, true},
+		{"1:05", 65, true},
+		{"5", 0, false},
+		{"1:2:3:4", 0, false},
+		{"aa:10", 0, false},
+	} {
+		got, err := parseClock(tc.clock)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("parseClock(%q) = %v, %v, want %v (ok %v)", tc.clock, got, err, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestPromptText(t *testing.T) {
+	timed := Transcript{Timed: true, Utterances: []Utterance{
+		{Role: RoleExecutive, Start: 65, Text: "Hello"},
+		{Speaker: "SPEAKER_02", Role: RoleUnknown, Start: 3725, Text: "Hi"},
+	}}
+	if got := timed.PromptText(); got != "[01:05] Executive: Hello\n[1:02:05] SPEAKER_02: Hi\n" {
+		t.Errorf("PromptText = %q", got)
+	}
+	untimed := Transcript{Utterances: []Utterance{{Role: RoleSeller, Text: "Hi"}}}
+	if got := untimed.PromptText(); got != "Seller: Hi\n" {
+		t.Errorf("PromptText without times = %q", got)
+	}
+}
@@ -0,0 +1,168 @@
+package transcript
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Speaker roles of an utterance
+const (
+	RoleExecutive = "executive" // IndiaMART sales/servicing/onboarding/support executive
+	RoleSeller    = "seller"    // the customer
+	RoleUnknown   = "unknown"
+)
+
+// Transcript formats recognised by Parse
+const (
+	FormatPlain = "plain"
+	FormatJSON  = "json"
+	FormatVTT   = "vtt"
+	FormatSRT   = "srt"
+)
+
+// Utterance is one speaker turn; Start and End are seconds from the start of the call
+type Utterance struct {
+	Speaker string  `json:"speaker,omitempty"` // label as found in the source, e.g. "Agent" or "SPEAKER_01"
+	Role    string  `json:"role"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+}
+
+// Transcript is a call transcript as ordered utterances
+type Transcript struct {
+	Format     string      `json:"format"`
+	Timed      bool        `json:"timed"` // Start/End come from the source
+	Utterances []Utterance `json:"utterances"`
+}
+
+// Text joins the utterances without speakers or timestamps
+func (t Transcript) Text() string {
+	texts := make([]string, 0, len(t.Utterances))
+	for _, utterance := range t.Utterances {
+		texts = append(texts, utterance.Text)
+	}
+	return strings.Join(texts, "\n")
+}
+
+// PromptText renders one line per turn, e.g. "[01:05] Executive: ...", for the LLM prompt
+func (t Transcript) PromptText() string {
+	var b strings.Builder
+	for _, utterance := range t.Utterances {
+		if t.Timed {
+			b.WriteString("[" + formatClock(utterance.Start) + "] ")
+		}
+		b.WriteString(roleLabel(utterance) + ": " + utterance.Text + "\n")
+	}
+	return b.String()
+}
+
+func roleLabel(utterance Utterance) string {
+	switch utterance.Role {
+	case RoleExecutive:
+		return "Executive"
+	case RoleSeller:
+		return "Seller"
+	}
+	if utterance.Speaker != "" {
+		return utterance.Speaker
+	}
+	return "Unknown"
+}
+
+func formatClock(seconds float64) string {
+	total := int(seconds)
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%02d:%02d", total/60, total%60)
+}
+
+var (
+	executiveLabels = []string{"executive", "agent", "exec", "representative", "rep", "support", "sales", "indiamart", "im"}
+	sellerLabels    = []string{"seller", "customer", "client", "supplier", "user", "buyer"}
+	labelWord       = regexp.MustCompile(`[a-z]+`)
+)
+
+// roleFromLabel reads the role from an explicit speaker label, RoleUnknown for labels like "Speaker 1"
+func roleFromLabel(label string) string {
+	for _, word := range labelWord.FindAllString(strings.ToLower(label), -1) {
+		if containsWord(executiveLabels, word) {
+			return RoleExecutive
+		}
+		if containsWord(sellerLabels, word) {
+			return RoleSeller
+		}
+	}
+	return RoleUnknown
+}
+
+func containsWord(words []string, word string) bool {
+	for _, candidate := range words {
+		if candidate == word {
+			return true
+		}
+	}
+	return false
+}
+
+// assignRoles fills the roles of utterances whose label did not give one. With generic labels
+// the speaker who mentions IndiaMART first is the executive, otherwise the first speaker is;
+// every other labelled speaker is the seller.
+func assignRoles(t *Transcript) {
+	roles := map[string]string{}
+	executive := ""
+	for _, utterance := range t.Utterances {
+		if utterance.Speaker == "" {
+			continue
+		}
+		if role := roleFromLabel(utterance.Speaker); role != RoleUnknown {
+			roles[utterance.Speaker] = role
+			if role == RoleExecutive && executive == "" {
+				executive = utterance.Speaker
+			}
+		}
+	}
+	if executive == "" {
+		for _, utterance := range t.Utterances {
+			if utterance.Speaker != "" && strings.Contains(strings.ToLower(utterance.Text), "indiamart") {
+				executive = utterance.Speaker
+				break
+			}
+		}
+	}
+	if executive == "" {
+		for _, utterance := range t.Utterances {
+			if utterance.Speaker != "" && roles[utterance.Speaker] == "" {
+				executive = utterance.Speaker
+				break
+			}
+		}
+	}
+
+	for i := range t.Utterances {
+		utterance := &t.Utterances[i]
+		switch {
+		case utterance.Role != "" && utterance.Role != RoleUnknown:
+		case utterance.Speaker == "":
+			utterance.Role = RoleUnknown
+		case roles[utterance.Speaker] != "":
+			utterance.Role = roles[utterance.Speaker]
+		case utterance.Speaker == executive:
+			utterance.Role = RoleExecutive
+		default:
+			utterance.Role = RoleSeller
+		}
+	}
+}
+
+// Diarized reports whether any utterance was attributed to the executive or the seller
+func (t Transcript) Diarized() bool {
+	for _, utterance := range t.Utterances {
+		if utterance.Role == RoleExecutive || utterance.Role == RoleSeller {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FakeTranscriber serves transcripts from Dir: <name>.txt, where name is the recording or
+// uploaded file name without extension, falling back to default.txt. Requests are recorded.
+type FakeTranscriber struct {
+	Dir string
+
+	mu       sync.Mutex
+	requests []Request
+}
+
+func NewFakeTranscriber(dir string) *FakeTranscriber {
+	return &FakeTranscriber{Dir: dir}
+}
+
+func (t *FakeTranscriber) Name() string {
+	return ProviderFake
+}
+
+// Requests returns a copy of the requests received so far
+func (t *FakeTranscriber) Requests() []Request {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Request{}, t.requests...)
+}
+
+func (t *FakeTranscriber) Transcribe(ctx context.Context, req Request) (string, error) {
+	t.mu.Lock()
+	t.requests = append(t.requests, req)
+	t.mu.Unlock()
+
+	name := req.FileName
+	if name == "" {
+		name = path.Base(strings.SplitN(req.RecordingURL, "?", 2)[0])
+	}
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	for _, candidate := range []string{name + ".txt", "default.txt"} {
+		if text, err := os.ReadFile(filepath.Join(t.Dir, filepath.Base(candidate))); err == nil {
+			return string(text), nil
+		}
+	}
+	return "", fmt.Errorf("fake transcriber has no transcript for %q in %s", name, t.Dir)
+}
@@ -0,0 +1,54 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	urlMedia "voice-hack-backend/utilities/urlMedia"
+)
+
+// MediaServiceTranscriber sends the recording link (or the uploaded audio) to the media
+// transcribe service, polls it while the transcription is pending and downloads the transcript it links to.
+// A Request with a MediaID resumes polling that transcription instead of sending the call again.
+// It uses the settings given to urlMedia.Configure.
+type MediaServiceTranscriber struct{}
+
+func NewMediaServiceTranscriber() *MediaServiceTranscriber {
+	return &MediaServiceTranscriber{}
+}
+
+func (t *MediaServiceTranscriber) Name() string {
+	return ProviderMediaService
+}
+
+func (t *MediaServiceTranscriber) Transcribe(ctx context.Context, req Request) (string, error) {
+	setInputParam := urlMedia.SetInputParamForTranscribeAPI(req.RecordingURL, req.CallType, req.ExecutiveID, req.Glid)
+
+	var getTextFromCallURl urlMedia.TranscribeAPIData
+	var err string
+	switch {
+	case req.MediaID != "":
+		getTextFromCallURl = urlMedia.TranscribeAPIData{MediaId: req.MediaID, Status: urlMedia.StatusPending}
+		if polled, pollErr := urlMedia.GetTranscriptionStatus(req.MediaID); pollErr == nil {
+			getTextFromCallURl = polled
+		}
+	case len(req.Audio) > 0:
+		getTextFromCallURl, err = urlMedia.CallTranscribeFileAPI(setInputParam, req.FileName, req.Audio)
+	default:
+		getTextFromCallURl, err = urlMedia.CallTranscribeAPI(setInputParam)
+	}
+	if err != "" {
+		return "", errors.New(err)
+	}
+	// The service may still be transcribing, poll until the transcript is ready
+	getTextFromCallURl, waitErr := urlMedia.WaitForTranscription(ctx, getTextFromCallURl)
+	if waitErr != nil {
+		return "", waitErr
+	}
+
+	text, textErr := urlMedia.GetTextFromURL(getTextFromCallURl.TranscriptionURL)
+	if textErr != nil {
+		return "", fmt.Errorf("GettextFrom url failed: %w", textErr)
+	}
+	return text, nil
+}
@@ -0,0 +1,78 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"voice-hack-backend/config"
+)
+
+// Transcriber names understood by Get
+const (
+	ProviderMediaService = "media_service" // IndiaMART media transcribe service
+	ProviderWhisper      = "whisper"       // OpenAI-compatible /v1/audio/transcriptions
+	ProviderFake         = "fake"          // transcripts read from local files
+)
+
+// Request describes one call to transcribe; either RecordingURL or Audio is set
+type Request struct {
+	RecordingURL string
+	Audio        []byte
+	FileName     string // name of the uploaded audio file
+	CallType     string // PNS, C2C or other, as sent in CallData
+	ExecutiveID  string
+	Glid         string
+	MediaID      string // media service transcription submitted by an earlier attempt, polled instead of submitting again
+}
+
+// Transcriber turns a call recording into transcript text
+type Transcriber interface {
+	Name() string
+	Transcribe(ctx context.Context, req Request) (string, error)
+}
+
+var (
+	transcribersMu     sync.RWMutex
+	transcribers       = map[string]Transcriber{}
+	defaultTranscriber = ProviderMediaService
+)
+
+func init() {
+	Configure(config.Get().Transcription)
+}
+
+// Configure (re)builds every transcriber from cfg and selects cfg.Provider as the default
+func Configure(cfg config.TranscriptionConfig) {
+	Register(NewMediaServiceTranscriber())
+	Register(NewWhisperTranscriber(cfg))
+	Register(NewFakeTranscriber(cfg.FakeDir))
+	SetDefault(cfg.Provider)
+}
+
+// Register adds or replaces a transcriber under its Name()
+func Register(transcriber Transcriber) {
+	transcribersMu.Lock()
+	defer transcribersMu.Unlock()
+	transcribers[transcriber.Name()] = transcriber
+}
+
+// SetDefault changes the transcriber returned by Get("")
+func SetDefault(name string) {
+	transcribersMu.Lock()
+	defer transcribersMu.Unlock()
+	defaultTranscriber = name
+}
+
+// Get returns the named transcriber, or the configured one when name is empty
+func Get(name string) (Transcriber, error) {
+	transcribersMu.RLock()
+	defer transcribersMu.RUnlock()
+	if name == "" {
+		name = defaultTranscriber
+	}
+	transcriber, ok := transcribers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown transcription provider %q", name)
+	}
+	return transcriber, nil
+}
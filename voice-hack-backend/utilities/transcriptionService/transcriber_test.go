@@ -0,0 +1,223 @@
+package transcription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"voice-hack-backend/config"
+	urlMedia "voice-hack-backend/utilities/urlMedia"
+)
+
+func TestFakeTranscriberReadsTranscriptFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "call-7.txt"), []byte("Seller: call seven"), 0o644)
+	os.WriteFile(filepath.Join(dir, "upload.txt"), []byte("Seller: uploaded"), 0o644)
+	fake := NewFakeTranscriber(dir)
+
+	for _, tc := range []struct {
+		req  Request
+		want string
+	}{
+		{Request{RecordingURL: "https://recordings.example.com/call-7.mp3?token=x"}, "Seller: call seven"},
+		{Request{FileName: "upload.wav", Audio: []byte("RIFF")}, "Seller: uploaded"},
+	} {
+		got, err := fake.Transcribe(context.Background(), tc.req)
+		if err != nil || got != tc.want {
+			t.Errorf("Transcribe(%+v) = %q, %v, want %q", tc.req, got, err, tc.want)
+		}
+	}
+	if _, err := fake.Transcribe(context.Background(), Request{RecordingURL: "https://recordings.example.com/unknown.mp3"}); err == nil {
+		t.Error("Transcribe found a transcript for an unknown call without default.txt")
+	}
+
+	os.WriteFile(filepath.Join(dir, "default.txt"), []byte("Seller: default"), 0o644)
+	if got, err := fake.Transcribe(context.Background(), Request{RecordingURL: "https://recordings.example.com/unknown.mp3"}); err != nil || got != "Seller: default" {
+		t.Errorf("Transcribe(unknown) = %q, %v, want default.txt", got, err)
+	}
+	if requests := fake.Requests(); len(requests) != 4 || requests[1].FileName != "upload.wav" {
+		t.Errorf("recorded requests = %+v", requests)
+	}
+}
+
+func TestConfigureSelectsDefaultTranscriber(t *testing.T) {
+	cfg := config.Defaults().Transcription
+	cfg.Provider = ProviderWhisper
+	Configure(cfg)
+	t.Cleanup(func() { Configure(config.Defaults().Transcription) })
+
+	transcriber, err := Get("")
+	if err != nil || transcriber.Name() != ProviderWhisper {
+		t.Fatalf("Get(\"\") = %v, %v, want the whisper transcriber", transcriber, err)
+	}
+	if _, err := Get("unknown"); err == nil {
+		t.Error("Get(unknown) succeeded")
+	}
+}
+
+// whisperUpload is what a stand-in Whisper server received
+type whisperUpload struct {
+	authorization string
+	fields        map[string]string
+	fileName      string
+	audio         string
+}
+
+// startWhisper serves the transcription endpoint, answering text with status, and the recording
+// at /recordings/call.mp3
+func startWhisper(t *testing.T, status int, text string) (*WhisperTranscriber, <-chan whisperUpload) {
+	t.Helper()
+	uploads := make(chan whisperUpload, 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/recordings/call.mp3", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("downloaded audio"))
+	})
+	mux.HandleFunc("/v1/audio/transcriptions", func(w http.ResponseWriter, r *http.Request) {
+		upload := whisperUpload{authorization: r.Header.Get("Authorization"), fields: map[string]string{}}
+		if err := r.ParseMultipartForm(1 << 20); err == nil {
+			for name, values := range r.MultipartForm.Value {
+				upload.fields[name] = values[0]
+			}
+			if file, header, err := r.FormFile("file"); err == nil {
+				audio, _ := io.ReadAll(file)
+				upload.fileName, upload.audio = header.Filename, string(audio)
+			}
+		}
+		uploads <- upload
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"text": text})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	cfg := config.Defaults().Transcription
+	cfg.Whisper.URL, cfg.Whisper.APIKey, cfg.Whisper.Model, cfg.Whisper.Language = server.URL+"/", "secret", "whisper-1", "hi"
+	return NewWhisperTranscriber(cfg), uploads
+}
+
+func TestWhisperTranscriberUploadsAudio(t *testing.T) {
+	whisper, uploads := startWhisper(t, http.StatusOK, "  namaste  ")
+
+	text, err := whisper.Transcribe(context.Background(), Request{Audio: []byte("uploaded audio"), FileName: "call.wav"})
+	if err != nil || text != "namaste" {
+		t.Fatalf("Transcribe = %q, %v, want the trimmed text", text, err)
+	}
+	upload := <-uploads
+	if upload.authorization != "Bearer secret" {
+		t.Errorf("Authorization header = %q", upload.authorization)
+	}
+	if upload.fileName != "call.wav" || upload.audio != "uploaded audio" {
+		t.Errorf("file = %q with %q", upload.fileName, upload.audio)
+	}
+	for field, want := range map[string]string{"model": "whisper-1", "response_format": "json", "language": "hi"} {
+		if upload.fields[field] != want {
+			t.Errorf("field %s = %q, want %q", field, upload.fields[field], want)
+		}
+	}
+}
+
+func TestWhisperTranscriberDownloadsRecording(t *testing.T) {
+	whisper, uploads := startWhisper(t, http.StatusOK, "namaste")
+
+	if _, err := whisper.Transcribe(context.Background(), Request{RecordingURL: strings.TrimSuffix(whisper.URL, "/") + "/recordings/call.mp3"}); err != nil {
+		t.Fatal(err)
+	}
+	if upload := <-uploads; upload.fileName != "call.mp3" || upload.audio != "downloaded audio" {
+		t.Errorf("file = %q with %q, want the downloaded recording", upload.fileName, upload.audio)
+	}
+}
+
+func TestWhisperTranscriberReportsStatus(t *testing.T) {
+	whisper, _ := startWhisper(t, http.StatusBadRequest, "")
+
+	_, err := whisper.Transcribe(context.Background(), Request{Audio: []byte("x"), FileName: "call.wav"})
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("Transcribe error = %v, want the status", err)
+	}
+}
+
+// mediaService is a stand-in media transcribe service: submissions get media m1, which stays pending
+// for pendingPolls status requests before linking to the transcript
+type mediaService struct {
+	submissions  atomic.Int32
+	polls        atomic.Int32
+	pendingPolls int32
+}
+
+func startMediaService(t *testing.T, pendingPolls int32, pollTimeout time.Duration) *mediaService {
+	t.Helper()
+	service := &mediaService{pendingPolls: pendingPolls}
+	var server *httptest.Server
+	reply := func(w http.ResponseWriter, data urlMedia.TranscribeAPIData) {
+		json.NewEncoder(w).Encode(urlMedia.TranscribeAPIResponse{Code: http.StatusOK, Status: "Success", Data: &data})
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transcribe", func(w http.ResponseWriter, r *http.Request) {
+		service.submissions.Add(1)
+		reply(w, urlMedia.TranscribeAPIData{MediaId: "m1", Status: "queued"})
+	})
+	mux.HandleFunc("/status/m1", func(w http.ResponseWriter, r *http.Request) {
+		if service.polls.Add(1) <= service.pendingPolls {
+			reply(w, urlMedia.TranscribeAPIData{MediaId: "m1", Status: "processing"})
+			return
+		}
+		reply(w, urlMedia.TranscribeAPIData{MediaId: "m1", Status: "completed", TranscriptionURL: server.URL + "/transcripts/m1.txt"})
+	})
+	mux.HandleFunc("/transcripts/m1.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Seller: transcribed by the media service"))
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	cfg := config.Defaults().Transcription
+	cfg.URL, cfg.StatusURL = server.URL+"/transcribe", server.URL+"/status/{media_id}"
+	cfg.PollInterval, cfg.PollMaxInterval, cfg.PollTimeout = time.Millisecond, time.Millisecond, pollTimeout
+	urlMedia.Configure(cfg)
+	t.Cleanup(func() { urlMedia.Configure(config.Defaults().Transcription) })
+	return service
+}
+
+func TestMediaServiceTranscriberPollsUntilCompleted(t *testing.T) {
+	service := startMediaService(t, 2, time.Second)
+
+	text, err := NewMediaServiceTranscriber().Transcribe(context.Background(), Request{RecordingURL: "https://recordings.example.com/call.mp3"})
+	if err != nil || text != "Seller: transcribed by the media service" {
+		t.Fatalf("Transcribe = %q, %v", text, err)
+	}
+	if service.submissions.Load() != 1 || service.polls.Load() != 3 {
+		t.Errorf("submissions = %d, polls = %d, want 1 and 3", service.submissions.Load(), service.polls.Load())
+	}
+}
+
+func TestMediaServiceTranscriberReportsProcessing(t *testing.T) {
+	startMediaService(t, 1000, 20*time.Millisecond)
+
+	_, err := NewMediaServiceTranscriber().Transcribe(context.Background(), Request{RecordingURL: "https://recordings.example.com/call.mp3"})
+	var processing *urlMedia.ProcessingError
+	if !errors.As(err, &processing) || processing.MediaID != "m1" {
+		t.Fatalf("Transcribe error = %v, want a ProcessingError for m1", err)
+	}
+	if !errors.Is(err, urlMedia.ErrTranscriptionProcessing) {
+		t.Error("ProcessingError does not match ErrTranscriptionProcessing")
+	}
+}
+
+func TestMediaServiceTranscriberResumesMediaID(t *testing.T) {
+	service := startMediaService(t, 1, time.Second)
+
+	text, err := NewMediaServiceTranscriber().Transcribe(context.Background(), Request{RecordingURL: "https://recordings.example.com/call.mp3", MediaID: "m1"})
+	if err != nil || text != "Seller: transcribed by the media service" {
+		t.Fatalf("Transcribe = %q, %v", text, err)
+	}
+	if service.submissions.Load() != 0 {
+		t.Errorf("the call was submitted %d time(s) again instead of polling m1", service.submissions.Load())
+	}
+}
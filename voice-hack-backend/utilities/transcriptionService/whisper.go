@@ -0,0 +1,121 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"voice-hack-backend/config"
+)
+
+// WhisperTranscriber calls an OpenAI/Whisper-compatible /v1/audio/transcriptions endpoint.
+// A recording URL is downloaded first since these servers only accept the audio itself.
+type WhisperTranscriber struct {
+	URL             string
+	APIKey          string
+	Model           string
+	Language        string
+	Timeout         time.Duration
+	DownloadTimeout time.Duration
+}
+
+func NewWhisperTranscriber(cfg config.TranscriptionConfig) *WhisperTranscriber {
+	return &WhisperTranscriber{
+		URL:             cfg.Whisper.URL,
+		APIKey:          cfg.Whisper.APIKey,
+		Model:           cfg.Whisper.Model,
+		Language:        cfg.Whisper.Language,
+		Timeout:         cfg.Timeout,
+		DownloadTimeout: cfg.DownloadTimeout,
+	}
+}
+
+func (t *WhisperTranscriber) Name() string {
+	return ProviderWhisper
+}
+
+func (t *WhisperTranscriber) Transcribe(ctx context.Context, req Request) (string, error) {
+	audio, fileName := req.Audio, req.FileName
+	if len(audio) == 0 {
+		downloaded, err := t.download(ctx, req.RecordingURL)
+		if err != nil {
+			return "", err
+		}
+		audio, fileName = downloaded, path.Base(req.RecordingURL)
+	}
+	if fileName == "" || fileName == "/" || fileName == "." {
+		fileName = "audio"
+	}
+
+	// ---- Build multipart body ----
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		return "", err
+	}
+	part.Write(audio)
+	writer.WriteField("model", t.Model)
+	writer.WriteField("response_format", "json")
+	if t.Language != "" {
+		writer.WriteField("language", t.Language)
+	}
+	writer.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(t.URL, "/")+"/v1/audio/transcriptions", &buf)
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
+	if t.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+t.APIKey)
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to call Whisper API: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Whisper response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Whisper API returned non-200 status: %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var parsed struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse Whisper response: %w", err)
+	}
+	return strings.TrimSpace(parsed.Text), nil
+}
+
+// download fetches the recording so it can be uploaded
+func (t *WhisperTranscriber) download(ctx context.Context, recordingURL string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.DownloadTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, recordingURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download recording: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("recording download returned status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
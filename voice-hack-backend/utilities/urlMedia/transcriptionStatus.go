@@ -0,0 +1,127 @@
+package urlMedia
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"voice-hack-backend/utilities/httpRequest"
+)
+
+// Transcription states, see TranscriptionState
+const (
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// ErrTranscriptionProcessing means the media service accepted the call but did not finish within
+// transcription.poll_timeout. Callers can retry later; see ProcessingError for the MediaId.
+var ErrTranscriptionProcessing = errors.New("transcription still processing")
+
+// ProcessingError is returned by WaitForTranscription when the deadline passes, it matches ErrTranscriptionProcessing
+type ProcessingError struct {
+	MediaID string
+	Status  string
+}
+
+func (e *ProcessingError) Error() string {
+	return fmt.Sprintf("transcription still processing (media %s, status %q), retry later", e.MediaID, e.Status)
+}
+
+func (e *ProcessingError) Unwrap() error {
+	return ErrTranscriptionProcessing
+}
+
+// TranscriptionState maps the service status to pending, completed or failed.
+// An empty status with a TranscriptionURL is the synchronous reply of older deployments.
+func TranscriptionState(data TranscribeAPIData) string {
+	switch strings.ToLower(strings.TrimSpace(data.Status)) {
+	case "completed", "complete", "done", "success", "succeeded", "transcribed":
+		return StatusCompleted
+	case "failed", "failure", "error", "cancelled", "canceled":
+		return StatusFailed
+	case "":
+		if data.TranscriptionURL != "" {
+			return StatusCompleted
+		}
+	}
+	return StatusPending
+}
+
+// GetTranscriptionStatus asks the status endpoint about one MediaId
+func GetTranscriptionStatus(mediaID string) (TranscribeAPIData, error) {
+	req := httpRequest.HttpRequest{
+		Method:  http.MethodGet,
+		URL:     strings.ReplaceAll(transcriptionConfig.StatusURL, "{media_id}", url.PathEscape(mediaID)),
+		Headers: map[string]any{},
+		Timeout: transcriptionConfig.Timeout,
+	}
+	resp := httpRequest.MakeHttpCall(req)
+	if resp.Err != nil {
+		return TranscribeAPIData{}, fmt.Errorf("failed to call transcription status API: %w", resp.Err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return TranscribeAPIData{}, fmt.Errorf("transcription status API returned non-200 status: %d", resp.StatusCode)
+	}
+
+	var parsed TranscribeAPIResponse
+	jsonBody, _ := json.Marshal(resp.Body)
+	if err := json.Unmarshal(jsonBody, &parsed); err != nil {
+		return TranscribeAPIData{}, fmt.Errorf("failed to parse transcription status response: %w", err)
+	}
+	if parsed.Data == nil {
+		return TranscribeAPIData{}, errors.New("transcription status response has no Data")
+	}
+	if parsed.Data.MediaId == "" {
+		parsed.Data.MediaId = mediaID
+	}
+	return *parsed.Data, nil
+}
+
+// WaitForTranscription polls a pending transcription with exponential backoff until it completes,
+// fails or transcription.poll_timeout passes, in which case a *ProcessingError is returned
+func WaitForTranscription(ctx context.Context, data TranscribeAPIData) (TranscribeAPIData, error) {
+	deadline := time.Now().Add(transcriptionConfig.PollTimeout)
+	interval := transcriptionConfig.PollInterval
+	for attempt := 1; ; attempt++ {
+		switch TranscriptionState(data) {
+		case StatusCompleted:
+			if data.TranscriptionURL == "" {
+				return data, fmt.Errorf("transcription %s completed without a TranscriptionURL", data.MediaId)
+			}
+			return data, nil
+		case StatusFailed:
+			return data, fmt.Errorf("transcription %s failed with status %q", data.MediaId, data.Status)
+		}
+
+		if data.MediaId == "" {
+			return data, fmt.Errorf("transcription is %q but the service returned no MediaId to poll", data.Status)
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return data, &ProcessingError{MediaID: data.MediaId, Status: data.Status}
+		}
+
+		select {
+		case <-ctx.Done():
+			return data, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		polled, err := GetTranscriptionStatus(data.MediaId)
+		if err != nil {
+			// Keep polling through transient status errors until the deadline
+			fmt.Printf("transcription %s status poll %d failed: %v\n", data.MediaId, attempt, err)
+		} else {
+			data = polled
+		}
+		interval *= 2
+		if interval > transcriptionConfig.PollMaxInterval {
+			interval = transcriptionConfig.PollMaxInterval
+		}
+	}
+}
@@ -11,10 +11,18 @@ import (
 	"net/http"
 	"os"
 	"time"
+	"voice-hack-backend/config"
 	globalconstant "voice-hack-backend/globalConstant"
 	"voice-hack-backend/utilities/httpRequest"
 )
 
+var transcriptionConfig = config.Defaults().Transcription
+
+// Configure sets the transcribe service URL and timeouts
+func Configure(cfg config.TranscriptionConfig) {
+	transcriptionConfig = cfg
+}
+
 type MetaKeys struct {
 	ReceiverId string `json:"receiverId"`
 	CallerId   string `json:"callerId"`
@@ -37,14 +45,18 @@ type TranscribeAPIData struct {
 	TranscriptionURL string `json:"TranscriptionURL"`
 }
 
-func SetInputParamForTranscribeAPI(callRecordingLink string, receiverId string, callerId string) TranscribeInput {
+// SetInputParamForTranscribeAPI builds the transcribe request; an empty callType is sent as PNS
+func SetInputParamForTranscribeAPI(callRecordingLink string, callType string, receiverId string, callerId string) TranscribeInput {
+	if callType == "" {
+		callType = "PNS"
+	}
 	return TranscribeInput{
 		CallRecordingLink: callRecordingLink,
-		CallType:          "PNS",
+		CallType:          callType,
 		MetaKeys: MetaKeys{
 			ReceiverId: receiverId,
 			CallerId:   callerId,
-			ModId:      "LMS",
+			ModId:      transcriptionConfig.ModID,
 		},
 	}
 }
@@ -63,14 +75,38 @@ func CallTranscribeAPI(input TranscribeInput) (resposne TranscribeAPIData, err s
 	writer.Close()
 	// fmt.Print("Multipart body prepared", writer.FormDataContentType())
 
+	return sendTranscribeRequest(&buf, writer)
+}
+
+// CallTranscribeFileAPI uploads the audio itself in the "file" part instead of a recording link
+func CallTranscribeFileAPI(input TranscribeInput, fileName string, audio []byte) (resposne TranscribeAPIData, err string) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	part, partErr := writer.CreateFormFile("file", fileName)
+	if partErr != nil {
+		return TranscribeAPIData{}, fmt.Sprintf("failed to prepare audio upload: %v", partErr)
+	}
+	part.Write(audio)
+	writer.WriteField("callType", input.CallType)
+
+	metaJSON, _ := json.Marshal(input.MetaKeys)
+	writer.WriteField("metaKeys", string(metaJSON))
+
+	writer.Close()
+
+	return sendTranscribeRequest(&buf, writer)
+}
+
+func sendTranscribeRequest(buf *bytes.Buffer, writer *multipart.Writer) (resposne TranscribeAPIData, err string) {
 	// ---- Prepare request ----
 	req := httpRequest.HttpRequest{
 		Method:          http.MethodPost,
-		URL:             "http://34.47.186.170/transcribe",
+		URL:             transcriptionConfig.URL,
 		Headers:         map[string]any{},
-		MultipartBody:   &buf,
+		MultipartBody:   buf,
 		MultipartWriter: writer,
-		Timeout:         40 * time.Second,
+		Timeout:         transcriptionConfig.Timeout,
 	}
 
 	// ---- Make the API call ----
@@ -106,7 +142,7 @@ func GetTextFromURL(url string) (string, error) {
 
 	// Create HTTP client with timeout
 	client := &http.Client{
-		Timeout: 15 * time.Second,
+		Timeout: transcriptionConfig.DownloadTimeout,
 	}
 
 	// Make GET request